@@ -0,0 +1,101 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"github.com/helm/chart-testing/v3/pkg/chart"
+	"github.com/helm/chart-testing/v3/pkg/config"
+)
+
+func newCleanupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Delete namespaces and releases left behind by aborted runs",
+		Long: heredoc.Doc(`
+			Delete the namespaces ct created for installing charts, along with the
+			Helm releases in them, which are older than '--older-than'. Runs which
+			are aborted, e.g. because the CI job is canceled, may leave them behind.
+
+			Namespaces created by ct are labeled 'app.kubernetes.io/managed-by=chart-testing'
+			and annotated with the build ID, chart, commit, and time they were created at
+			('chart-testing.helm.sh/build-id', 'chart-testing.helm.sh/chart',
+			'chart-testing.helm.sh/commit', and 'chart-testing.helm.sh/created-at').
+			Pass '--build-id' to only delete the namespaces of a specific build.
+
+			Namespaces passed to 'ct install' with '--namespace' are not created by
+			ct and are therefore never deleted.`),
+		RunE: cleanup,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&cfgFile, "config", "", "Config file")
+	flags.Bool("print-config", false, heredoc.Doc(`
+		Prints the configuration to stderr (caution: setting this may
+		expose sensitive data when helm-repo-extra-args contains passwords)`))
+	flags.Duration("older-than", 2*time.Hour, heredoc.Doc(`
+		The minimum age of the namespaces to delete (e.g. '2h'). Keep it above the
+		duration of a run, so that namespaces of runs in progress are not deleted`))
+	flags.String("build-id", "", heredoc.Doc(`
+		Only delete the namespaces created by runs with the given '--build-id'`))
+	flags.String("helm-extra-args", "", heredoc.Doc(`
+		Additional arguments for Helm. Must be passed as a single quoted string
+		(e.g. '--timeout 500s')`))
+	flags.String("helm-client", "helm", heredoc.Doc(`
+		The client used to run Helm operations. One of 'helm', which runs the helm
+		executable, or 'sdk', which uses the Helm SDK built into ct`))
+	flags.String("kubernetes-client", "kubectl", heredoc.Doc(`
+		The client used to talk to the cluster. One of 'kubectl', which runs the
+		kubectl executable, or 'client-go', which calls the Kubernetes API directly
+		using the current context of the kubeconfig`))
+	flags.StringSlice("kube-contexts", []string{}, heredoc.Doc(`
+		Contexts of the kubeconfig to clean up. If not specified, the current
+		context is used`))
+	flags.Duration("cleanup-grace-period", 3*time.Minute, heredoc.Doc(`
+		The time given to finish deleting the current namespace once ct receives
+		SIGINT or SIGTERM. No further namespaces are deleted on the signal`))
+	flags.Bool("debug", false, heredoc.Doc(`
+		Print CLI calls of external tools to stdout (caution: setting this may
+		expose sensitive data when helm-repo-extra-args contains passwords)`))
+	return cmd
+}
+
+func cleanup(cmd *cobra.Command, _ []string) error {
+	printConfig, err := cmd.Flags().GetBool("print-config")
+	if err != nil {
+		return err
+	}
+	configuration, err := config.LoadConfiguration(cfgFile, cmd, printConfig)
+	if err != nil {
+		return fmt.Errorf("failed loading configuration: %w", err)
+	}
+
+	testing, err := chart.NewTesting(*configuration)
+	if err != nil {
+		return err
+	}
+	ctx, cleanupCtx, stop := handleSignals(configuration.CleanupGracePeriod)
+	defer stop()
+	err = testing.WithCancellation(ctx, cleanupCtx).CleanupNamespaces()
+	if err := interruption(ctx, err); err != nil {
+		return fmt.Errorf("failed cleaning up namespaces: %w", err)
+	}
+	return nil
+}
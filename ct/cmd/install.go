@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/helm/chart-testing/v3/pkg/chart"
@@ -42,6 +43,14 @@ func newInstallCmd() *cobra.Command {
 			* previous chart revision => current chart version (if non-breaking SemVer change)
 			* current chart version => current chart version
 
+			Released versions to upgrade from can be configured with --upgrade-from.
+			With --rollback, each upgraded release is rolled back to its previous
+			revision and tested again.
+
+			Before 'helm test' is run, ct waits for the release's Deployments,
+			StatefulSets, DaemonSets, Jobs, PersistentVolumeClaims, and Services of
+			type LoadBalancer to become ready.
+
 			Charts may have multiple custom values files matching the glob pattern
 			'*-values.yaml' in a directory named 'ci' in the root of the chart's
 			directory. The chart is installed and tested for each of these files.
@@ -64,6 +73,40 @@ func addInstallFlags(flags *flag.FlagSet) {
 	flags.Bool("upgrade", false, heredoc.Doc(`
 		Whether to test an in-place upgrade of each chart from its previous revision if the
 		current version should not introduce a breaking change according to the SemVer spec`))
+	flags.StringSlice("upgrade-from", []string{}, heredoc.Doc(`
+		The sources of the versions to test upgrades from when --upgrade has been passed.
+		One of 'merge-base' for the chart's revision at the merge base with the target
+		branch, 'git-tags' for the chart's revisions tagged '<chart>-<version>', the
+		URL of an OCI repository the chart is pushed to (e.g. 'oci://ghcr.io/org/charts'),
+		or the URL or path of a chart repository's 'index.yaml'. May be specified
+		multiple times or separate values with commas (default: merge-base)`))
+	flags.Int("upgrade-versions", 1, heredoc.Doc(`
+		The number of latest released versions per source, except 'merge-base', to
+		test upgrades from. Only versions which should not introduce a breaking change
+		according to the SemVer spec are considered`))
+	flags.Bool("upgrade-chain", false, heredoc.Doc(`
+		Install the oldest of the released versions to test upgrades from and upgrade
+		the release through the newer versions to the current chart version, instead
+		of testing the upgrade from each version separately`))
+	flags.Bool("rollback", false, heredoc.Doc(`
+		When --upgrade has been passed, roll each successfully upgraded release back
+		to its previous revision with 'helm rollback', wait for its resources to
+		become ready, and run 'helm test' again`))
+	flags.Duration("readiness-timeout", 10*time.Minute, heredoc.Doc(`
+		The maximum time to wait for the Deployments, StatefulSets, DaemonSets, Jobs,
+		PersistentVolumeClaims, and LoadBalancer Services of a release to become ready
+		after installing, upgrading, or rolling back a chart (e.g. '15m')`))
+	flags.Int("retries", 0, heredoc.Doc(`
+		The number of times to retry waiting for the resources of a release to become
+		ready and 'helm test' if they fail. Charts which pass only after a retry are
+		marked as flaky in the results`))
+	flags.Duration("retry-backoff", 10*time.Second, heredoc.Doc(`
+		The time to wait before the first retry. It is doubled for each further retry`))
+	flags.StringSlice("quarantine", []string{}, heredoc.Doc(`
+		Charts, given by name or path, or CI values files, given by path (e.g.
+		'charts/foo/ci/ha-values.yaml'), which are known to be flaky. Their failures
+		are reported but do not fail the run. May be specified multiple times or
+		separate values with commas`))
 	flags.Bool("skip-missing-values", false, heredoc.Doc(`
 		When --upgrade has been passed, this flag will skip testing CI values files from the
 		previous chart revision if they have been deleted or renamed at the current chart
@@ -71,6 +114,12 @@ func addInstallFlags(flags *flag.FlagSet) {
 	flags.String("namespace", "", heredoc.Doc(`
 		Namespace to install the release(s) into. If not specified, each release will be
 		installed in its own randomly generated namespace`))
+	flags.String("namespace-profile", "", heredoc.Doc(`
+		The name of the profile from 'namespace-profiles' in the configuration file to
+		apply to the namespaces created for installing charts. A profile enforces a Pod
+		Security Standard and may create a ResourceQuota, a LimitRange, and a
+		NetworkPolicy denying all traffic but DNS lookups, so installing charts which
+		do not comply fails. Not applied with --namespace`))
 	flags.String("release-name", "", heredoc.Doc(`
 		Name for the release. If not specified, is set to the chart name and a random 
 		identifier.`))
@@ -82,11 +131,21 @@ func addInstallFlags(flags *flag.FlagSet) {
 		(e.g. "--set=name=value"`))
 	flags.Bool("skip-clean-up", false, heredoc.Doc(`
 		Skip resources clean-up. Used if need to continue other flows or keep it around.`))
+	flags.String("kubernetes-client", "kubectl", heredoc.Doc(`
+		The client used to talk to the cluster. One of 'kubectl', which runs the
+		kubectl executable, or 'client-go', which calls the Kubernetes API directly
+		using the current context of the kubeconfig`))
+	flags.StringSlice("kube-contexts", []string{}, heredoc.Doc(`
+		Contexts of the kubeconfig to install and test charts in. Each chart is
+		installed and tested once per context, and results are reported per chart
+		and context. If not specified, the current context is used`))
+	flags.String("artifacts-dir", "", heredoc.Doc(`
+		Write the events, pod descriptions, container logs, and rendered manifests
+		of each release to files in the specified directory, organized per chart,
+		values file, and release, instead of printing them`))
 }
 
 func install(cmd *cobra.Command, _ []string) error {
-	fmt.Println("Installing charts...")
-
 	printConfig, err := cmd.Flags().GetBool("print-config")
 	if err != nil {
 		return err
@@ -95,18 +154,28 @@ func install(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed loading configuration: %w", err)
 	}
+	out := progressOutput(configuration)
+	fmt.Fprintln(out, "Installing charts...")
 
 	testing, err := chart.NewTesting(*configuration)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(out, err)
 	}
-	results, err := testing.InstallCharts()
+	ctx, cleanupCtx, stop := handleSignals(configuration.CleanupGracePeriod)
+	defer stop()
+	results, err := testing.WithCancellation(ctx, cleanupCtx).InstallCharts()
 	testing.PrintResults(results)
+	if err := testing.WriteJUnitReport("install", results); err != nil {
+		return err
+	}
+	if err := testing.WriteResultsFile(results); err != nil {
+		return err
+	}
 
-	if err != nil {
+	if err := interruption(ctx, err); err != nil {
 		return fmt.Errorf("failed installing charts: %w", err)
 	}
 
-	fmt.Println("All charts installed successfully")
+	fmt.Fprintln(out, "All charts installed successfully")
 	return nil
 }
@@ -31,6 +31,9 @@ func newLintCmd() *cobra.Command {
 		Long: heredoc.Doc(`
 			Run 'helm lint', version checking, YAML schema validation
 			on 'Chart.yaml', YAML linting on 'Chart.yaml' and 'values.yaml',
+			JSON schema validation of values files against 'values.schema.json',
+			JSON schema validation of rendered manifests (if '--schema-dir' is set),
+			comparison of rendered manifests to snapshots in 'ci/__snapshots__',
 			and maintainer validation on
 
 			* changed charts (default)
@@ -57,6 +60,11 @@ func addLintFlags(flags *flag.FlagSet) {
 		The config file for YAML linting. If not specified, 'lintconf.yaml'
 		is searched in the current directory, '$HOME/.ct', and '/etc/ct', in
 		that order`))
+	flags.String("yaml-linter", "yamllint", heredoc.Doc(`
+		The implementation used for YAML linting and schema validation. Either
+		'yamllint' to run the yamllint and yamale executables or 'native' to use
+		built-in implementations, which support the most common yamllint rules and
+		the Yamale schema syntax and need no Python installation`))
 	flags.String("chart-yaml-schema", "", heredoc.Doc(`
 		The schema for chart.yml validation. If not specified, 'chart_schema.yaml'
 		is searched in the current directory, '$HOME/.ct', and '/etc/ct', in
@@ -69,6 +77,22 @@ func addLintFlags(flags *flag.FlagSet) {
 		Enable schema validation of 'Chart.yaml' using Yamale`))
 	flags.Bool("validate-yaml", true, heredoc.Doc(`
 		Enable linting of 'Chart.yaml' and values files`))
+	flags.Bool("validate-values-schema", true, heredoc.Doc(`
+		Enable validation of 'values.yaml' and each CI values file merged over
+		it against the chart's 'values.schema.json', if present`))
+	flags.StringSlice("kube-versions", []string{}, heredoc.Doc(`
+		Kubernetes versions to check the API versions of rendered manifests
+		against, in addition to the versions allowed by the chart's 'kubeVersion'
+		(e.g. '1.25,1.29'). Linting fails if an object uses an API version which
+		is not available in one of these versions`))
+	addValidateFlags(flags)
+	flags.Bool("update-snapshots", false, heredoc.Doc(`
+		Write the manifests rendered with each CI values file to the snapshot
+		files in the chart's 'ci/__snapshots__' directory instead of failing if
+		they differ`))
+	flags.Bool("keep-going", false, heredoc.Doc(`
+		Run all lint checks and lint with every CI values file even if a check
+		fails, and report all failures of a chart at once`))
 	flags.Bool("skip-helm-dependencies", false, heredoc.Doc(`
 		Skip running 'helm dependency build' before linting`))
 	flags.StringSlice("additional-commands", []string{}, heredoc.Doc(`
@@ -79,8 +103,6 @@ func addLintFlags(flags *flag.FlagSet) {
 }
 
 func lint(cmd *cobra.Command, _ []string) error {
-	fmt.Println("Linting charts...")
-
 	printConfig, err := cmd.Flags().GetBool("print-config")
 	if err != nil {
 		return err
@@ -89,18 +111,28 @@ func lint(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed loading configuration: %w", err)
 	}
+	out := progressOutput(configuration)
+	fmt.Fprintln(out, "Linting charts...")
 
 	testing, err := chart.NewTesting(*configuration)
 	if err != nil {
 		return err
 	}
-	results, err := testing.LintCharts()
+	ctx, cleanupCtx, stop := handleSignals(configuration.CleanupGracePeriod)
+	defer stop()
+	results, err := testing.WithCancellation(ctx, cleanupCtx).LintCharts()
 	testing.PrintResults(results)
+	if err := testing.WriteJUnitReport("lint", results); err != nil {
+		return err
+	}
+	if err := testing.WriteResultsFile(results); err != nil {
+		return err
+	}
 
-	if err != nil {
+	if err := interruption(ctx, err); err != nil {
 		return fmt.Errorf("failed linting charts: %w", err)
 	}
 
-	fmt.Println("All charts linted successfully")
+	fmt.Fprintln(out, "All charts linted successfully")
 	return nil
 }
@@ -40,8 +40,6 @@ func newLintAndInstallCmd() *cobra.Command {
 }
 
 func lintAndInstall(cmd *cobra.Command, _ []string) error {
-	fmt.Println("Linting and installing charts...")
-
 	printConfig, err := cmd.Flags().GetBool("print-config")
 	if err != nil {
 		return err
@@ -50,18 +48,28 @@ func lintAndInstall(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed loading configuration: %w", err)
 	}
+	out := progressOutput(configuration)
+	fmt.Fprintln(out, "Linting and installing charts...")
 
 	testing, err := chart.NewTesting(*configuration)
 	if err != nil {
 		return err
 	}
-	results, err := testing.LintAndInstallCharts()
+	ctx, cleanupCtx, stop := handleSignals(configuration.CleanupGracePeriod)
+	defer stop()
+	results, err := testing.WithCancellation(ctx, cleanupCtx).LintAndInstallCharts()
 	testing.PrintResults(results)
+	if err := testing.WriteJUnitReport("lint-and-install", results); err != nil {
+		return err
+	}
+	if err := testing.WriteResultsFile(results); err != nil {
+		return err
+	}
 
-	if err != nil {
+	if err := interruption(ctx, err); err != nil {
 		return fmt.Errorf("failed linting and installing charts: %w", err)
 	}
 
-	fmt.Println("All charts linted and installed successfully")
+	fmt.Fprintln(out, "All charts linted and installed successfully")
 	return nil
 }
@@ -0,0 +1,66 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintJSONOutputIsTheOnlyOutputOnStdout(t *testing.T) {
+	stdout, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	require.NoError(t, err)
+	defer stdout.Close()
+	originalStdout := os.Stdout
+	os.Stdout = stdout
+	defer func() { os.Stdout = originalStdout }()
+
+	cmd := NewRootCmd()
+	cmd.SetArgs([]string{
+		"lint",
+		"--output", "json",
+		"--helm-client", "sdk",
+		"--charts", "../../pkg/chart/test_charts/simple-deployment",
+		"--check-version-increment=false",
+		"--validate-maintainers=false",
+		"--validate-chart-schema=false",
+		"--validate-yaml=false",
+		"--skip-helm-dependencies",
+	})
+	require.NoError(t, cmd.Execute())
+
+	output, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	var document struct {
+		Version int `json:"version"`
+		Results []struct {
+			Chart  string `json:"chart"`
+			Step   string `json:"step"`
+			Status string `json:"status"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(output, &document), "stdout is not a JSON document:\n%s", output)
+	assert.Equal(t, 1, document.Version)
+	require.NotEmpty(t, document.Results)
+	for _, result := range document.Results {
+		assert.Equal(t, "nginx", result.Chart)
+		assert.Equal(t, "passed", result.Status)
+	}
+}
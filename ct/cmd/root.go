@@ -16,11 +16,15 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+
+	"github.com/helm/chart-testing/v3/pkg/config"
 )
 
 var (
@@ -45,7 +49,9 @@ func NewRootCmd() *cobra.Command {
 	cmd.AddCommand(newLintCmd())
 	cmd.AddCommand(newInstallCmd())
 	cmd.AddCommand(newLintAndInstallCmd())
+	cmd.AddCommand(newValidateCmd())
 	cmd.AddCommand(newListChangedCmd())
+	cmd.AddCommand(newCleanupCmd())
 	cmd.AddCommand(newVersionCmd())
 	cmd.AddCommand(newGenerateDocsCmd())
 
@@ -57,9 +63,18 @@ func NewRootCmd() *cobra.Command {
 // Execute runs the application
 func Execute() {
 	if err := NewRootCmd().Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// progressOutput returns the writer for progress messages. With '--output json', stdout is reserved for the JSON
+// document of the results, so they are written to stderr instead.
+func progressOutput(configuration *config.Configuration) io.Writer {
+	if configuration.Output == "json" {
+		return os.Stderr
 	}
+	return os.Stdout
 }
 
 func addCommonFlags(flags *pflag.FlagSet) {
@@ -81,6 +96,9 @@ func addCommonFlags(flags *pflag.FlagSet) {
 		Change the delimiters for github to create collapsible groups
 		for command output`))
 	flags.Bool("use-helmignore", false, "Use .helmignore when identifying changed charts")
+	flags.Bool("include-dependents", false, heredoc.Doc(`
+		Add charts that depend on changed charts via 'file://' dependencies in
+		their 'Chart.yaml' to the changed charts, including transitive dependents`))
 }
 
 func addCommonLintAndInstallFlags(flags *pflag.FlagSet) {
@@ -109,6 +127,35 @@ func addCommonLintAndInstallFlags(flags *pflag.FlagSet) {
 		multiple times or separate values with commas`))
 	flags.StringSlice("helm-dependency-extra-args", []string{}, heredoc.Doc(`
 		Additional arguments for 'helm dependency build' (e.g. ["--skip-refresh"]`))
+	flags.Int("parallelism", 1, heredoc.Doc(`
+		The number of charts to process in parallel. The output of each chart
+		is buffered and printed as one block once the chart has been processed`))
+	flags.Duration("chart-timeout", 0, heredoc.Doc(`
+		The maximum time a chart may take to be processed (e.g. '15m'). Once it is
+		exceeded, all processes run for the chart are killed, its release is cleaned up,
+		and the chart fails with a timeout. Disabled if zero`))
+	flags.Duration("total-timeout", 0, heredoc.Doc(`
+		The maximum time processing all charts may take (e.g. '1h'). Once it is exceeded,
+		charts being processed are canceled like with '--chart-timeout' and charts not yet
+		started fail with a timeout. Disabled if zero`))
+	flags.Duration("cleanup-grace-period", 3*time.Minute, heredoc.Doc(`
+		The time given to clean up releases, namespaces, and worktrees once ct receives
+		SIGINT or SIGTERM. No new charts are started and running commands are killed
+		on the signal. ct exits with code 128 plus the signal number, e.g. 143 for
+		SIGTERM, once cleaning up is done, or right away on a second signal`))
+	flags.String("junit-report", "", heredoc.Doc(`
+		Write the results to the specified file as JUnit XML report with one
+		testcase per chart, CI values file, and step`))
+	flags.String("output", "text", heredoc.Doc(`
+		The format of the results printed at the end of a run. One of 'text' or 'json'.
+		With 'json', the results are the only output on stdout, everything else is
+		written to stderr`))
+	flags.String("results-file", "", heredoc.Doc(`
+		Write the results to the specified file as versioned JSON document with one
+		entry per chart, CI values file, and step`))
+	flags.String("helm-client", "helm", heredoc.Doc(`
+		The client used to run Helm operations. One of 'helm', which runs the helm
+		executable, or 'sdk', which uses the Helm SDK built into ct`))
 	flags.Bool("debug", false, heredoc.Doc(`
 		Print CLI calls of external tools to stdout (caution: setting this may
 		expose sensitive data when helm-repo-extra-args contains passwords)`))
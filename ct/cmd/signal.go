@@ -0,0 +1,113 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// forcedExitDelay is the time given to cleanups to return once they have been canceled at the end of the grace period.
+const forcedExitDelay = 15 * time.Second
+
+// interruptedError is the cause of the cancellation of a run by a signal.
+type interruptedError struct {
+	signal os.Signal
+}
+
+func (e interruptedError) Error() string {
+	return fmt.Sprintf("interrupted by signal: %s", e.signal)
+}
+
+// exitCode returns the exit code of a process terminated by the signal, i.e. 128 plus the signal number.
+func (e interruptedError) exitCode() int {
+	if s, ok := e.signal.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// exitCode returns the code ct exits with because of err.
+func exitCode(err error) int {
+	var interrupted interruptedError
+	if errors.As(err, &interrupted) {
+		return interrupted.exitCode()
+	}
+	return 1
+}
+
+// handleSignals returns a context which is canceled once ct receives SIGINT or SIGTERM, so no new charts are started
+// and commands in flight are killed, and a context for cleaning up, which is canceled gracePeriod after the signal.
+// ct exits right away on a second signal or if cleaning up does not finish shortly after the grace period. stop must
+// be called once the run is over.
+func handleSignals(gracePeriod time.Duration) (ctx context.Context, cleanupCtx context.Context, stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cleanupCtx, cancelCleanup := context.WithCancelCause(context.Background())
+	done := make(chan struct{})
+	go func() {
+		var interrupted interruptedError
+		select {
+		case sig := <-signals:
+			interrupted = interruptedError{sig}
+		case <-done:
+			return
+		}
+		fmt.Fprintf(os.Stderr, "\nReceived signal %s: canceling and cleaning up for at most %s...\n", interrupted.signal, gracePeriod)
+		cancel(interrupted)
+
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(os.Stderr, "\nReceived signal %s again: exiting without cleaning up\n", sig)
+			os.Exit(interrupted.exitCode())
+		case <-time.After(gracePeriod):
+			fmt.Fprintf(os.Stderr, "\nCleaning up did not finish within %s: canceling cleanup\n", gracePeriod)
+			cancelCleanup(interrupted)
+		case <-done:
+			return
+		}
+
+		select {
+		case <-signals:
+		case <-time.After(forcedExitDelay):
+		case <-done:
+			return
+		}
+		os.Exit(interrupted.exitCode())
+	}()
+
+	stop = func() {
+		signal.Stop(signals)
+		close(done)
+		cancel(nil)
+		cancelCleanup(nil)
+	}
+	return ctx, cleanupCtx, stop
+}
+
+// interruption returns the signal the run has been interrupted by as error, if any, or else err.
+func interruption(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); cause != nil {
+		return cause
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/helm/chart-testing/v3/pkg/chart"
+	"github.com/helm/chart-testing/v3/pkg/config"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+)
+
+func newValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the manifests rendered from a chart",
+		Long: heredoc.Doc(`
+			Render and validate every object against its Kubernetes JSON schema
+			without accessing a cluster for
+
+			* changed charts (default)
+			* specific charts (--charts)
+			* all charts (--all)
+
+			in given chart directories.
+
+			Schemas are read from the directory specified with '--schema-dir', in
+			which they are looked up as '<kind>-<group>-<version>.json' (e.g.
+			'deployment-apps-v1.json', or 'configmap-v1.json' for the core group)
+			or '<group>/<kind>_<version>.json' (e.g.
+			'monitoring.coreos.com/servicemonitor_v1.json'). Schemas of custom
+			resources are also taken from the CRDs in the chart's 'crds' directory.
+
+			Charts may have multiple custom values files matching the glob pattern
+			'*-values.yaml' in a directory named 'ci' in the root of the chart's
+			directory. The chart is validated for each of these files. If no custom
+			values file is present, the chart is validated with defaults.`),
+		RunE: validate,
+	}
+
+	flags := cmd.Flags()
+	addValidateFlags(flags)
+	flags.Bool("skip-helm-dependencies", false, heredoc.Doc(`
+		Skip running 'helm dependency build' before validating`))
+	addCommonLintAndInstallFlags(flags)
+	return cmd
+}
+
+func addValidateFlags(flags *flag.FlagSet) {
+	flags.String("schema-dir", "", heredoc.Doc(`
+		The directory containing the Kubernetes JSON schemas to validate rendered
+		manifests against, e.g. a local copy of the schemas of a Kubernetes version
+		from the kubernetes-json-schema project. Schemas of custom resources are
+		also taken from the chart's 'crds' directory`))
+	flags.Bool("ignore-missing-schemas", false, heredoc.Doc(`
+		Skip objects for which no schema is found instead of failing validation`))
+}
+
+func validate(cmd *cobra.Command, _ []string) error {
+	printConfig, err := cmd.Flags().GetBool("print-config")
+	if err != nil {
+		return err
+	}
+	configuration, err := config.LoadConfiguration(cfgFile, cmd, printConfig)
+	if err != nil {
+		return fmt.Errorf("failed loading configuration: %w", err)
+	}
+	out := progressOutput(configuration)
+	fmt.Fprintln(out, "Validating charts...")
+
+	testing, err := chart.NewTesting(*configuration)
+	if err != nil {
+		return err
+	}
+	ctx, cleanupCtx, stop := handleSignals(configuration.CleanupGracePeriod)
+	defer stop()
+	results, err := testing.WithCancellation(ctx, cleanupCtx).ValidateCharts()
+	testing.PrintResults(results)
+	if err := testing.WriteJUnitReport("validate", results); err != nil {
+		return err
+	}
+	if err := testing.WriteResultsFile(results); err != nil {
+		return err
+	}
+
+	if err := interruption(ctx, err); err != nil {
+		return fmt.Errorf("failed validating charts: %w", err)
+	}
+
+	fmt.Fprintln(out, "All charts validated successfully")
+	return nil
+}
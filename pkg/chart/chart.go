@@ -15,15 +15,25 @@
 package chart
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/Masterminds/semver"
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/go-multierror"
 	helmignore "helm.sh/helm/v3/pkg/ignore"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/helm/chart-testing/v3/pkg/config"
 	"github.com/helm/chart-testing/v3/pkg/exec"
@@ -44,6 +54,8 @@ const maxNameLength = 63
 //
 // RemoveWorktree removes the working tree at the specified path.
 //
+// CurrentCommit returns the SHA1 of HEAD.
+//
 // MergeBase returns the SHA1 of the merge base of commit1 and commit2.
 //
 // ListChangedFilesInDirs diffs commit against HEAD and returns changed files for the specified dirs.
@@ -54,16 +66,20 @@ const maxNameLength = 63
 // and returns nil if valid.
 //
 // BranchExists checks whether a given branch exists in the git repository.
+//
+// ListTags returns the tags matching the specified pattern.
 type Git interface {
 	FileExistsOnBranch(file string, remote string, branch string) bool
 	Show(file string, remote string, branch string) (string, error)
 	AddWorktree(path string, ref string) error
 	RemoveWorktree(path string) error
+	CurrentCommit() (string, error)
 	MergeBase(commit1 string, commit2 string) (string, error)
 	ListChangedFilesInDirs(commit string, dirs ...string) ([]string, error)
 	GetURLForRemote(remote string) (string, error)
 	ValidateRepository() error
 	BranchExists(branch string) bool
+	ListTags(pattern string) ([]string, error)
 }
 
 // Helm is the interface that wraps Helm operations
@@ -83,10 +99,22 @@ type Git interface {
 // UpgradeWithValues runs `helm upgrade` against an existing release using the specified values file.
 // Pass a zero value for valuesFile in order to run install without specifying a values file.
 //
+// Rollback runs `helm rollback` against an existing release to roll it back to its previous revision.
+//
 // Test runs `helm test` against an existing release. Set the cleanup argument to true in order
 // to clean up test pods created by helm after the test command completes.
 //
 // DeleteRelease purges the specified Helm release.
+//
+// GetManifest returns the rendered manifests of the specified Helm release.
+//
+// ListReleases returns the names of all Helm releases in the specified namespace.
+//
+// Pull downloads the specified chart, i.e. the URL of a chart archive or an OCI reference, and unpacks it into
+// destDir. Pass a zero value for version in order to pull the latest version.
+//
+// Template renders the chart with the specified values file for the specified Kubernetes version and returns the
+// manifests. Pass zero values for valuesFile or kubeVersion in order to use the defaults.
 type Helm interface {
 	AddRepo(name string, url string, extraArgs []string) error
 	BuildDependencies(chart string) error
@@ -94,16 +122,27 @@ type Helm interface {
 	LintWithValues(chart string, valuesFile string) error
 	InstallWithValues(chart string, valuesFile string, namespace string, release string) error
 	UpgradeWithValues(chart string, valuesFile string, namespace string, release string) error
+	Rollback(namespace string, release string) error
 	Test(namespace string, release string) error
-	DeleteRelease(namespace string, release string)
+	DeleteRelease(namespace string, release string) error
+	GetManifest(namespace string, release string) (string, error)
+	ListReleases(namespace string) ([]string, error)
+	Pull(chart string, version string, destDir string) error
+	Template(chart string, valuesFile string, kubeVersion string) (string, error)
 	Version() (string, error)
 }
 
 // Kubectl is the interface that wraps kubectl operations
 //
-// # DeleteNamespace deletes a namespace
+// # CreateNamespace creates a namespace with the given labels and annotations
+//
+// # CreateObjects creates the ResourceQuotas, LimitRanges, and NetworkPolicies of a namespace profile in a namespace
+//
+// # GetNamespaces gets the namespaces matching a label selector
 //
-// # WaitForDeployments waits for a deployment to become ready
+// # DeleteNamespace deletes a namespace, force-deleting its contents if it does not terminate
+//
+// # WaitForResources waits for the workloads, jobs, volume claims, and load balancers of a release to become ready
 //
 // # GetPodsforDeployment gets all pods for a deployment
 //
@@ -115,18 +154,23 @@ type Helm interface {
 //
 // # Logs prints the logs of container
 //
+// # PreviousLogs prints the logs of the previous instance of a restarted container
+//
 // # GetInitContainers gets all init containers of pod
 //
 // GetContainers gets all containers of pod
 type Kubectl interface {
-	CreateNamespace(namespace string) error
-	DeleteNamespace(namespace string)
-	WaitForDeployments(namespace string, selector string) error
+	CreateNamespace(namespace string, labels map[string]string, annotations map[string]string) error
+	CreateObjects(namespace string, objects []runtime.Object) error
+	GetNamespaces(selector string) ([]corev1.Namespace, error)
+	DeleteNamespace(namespace string) error
+	WaitForResources(namespace string, selector string) error
 	GetPodsforDeployment(namespace string, deployment string) ([]string, error)
 	GetPods(args ...string) ([]string, error)
 	GetEvents(namespace string) error
 	DescribePod(namespace string, pod string) error
 	Logs(namespace string, pod string, container string) error
+	PreviousLogs(namespace string, pod string, container string) error
 	GetInitContainers(namespace string, pod string) ([]string, error)
 	GetContainers(namespace string, pod string) ([]string, error)
 }
@@ -181,6 +225,15 @@ func (c *Chart) Yaml() *util.ChartYaml {
 	return c.yaml
 }
 
+// kubeVersion returns the Kubernetes version constraint of the chart, which is empty if the chart has none or its
+// Chart.yaml has not been read.
+func (c *Chart) kubeVersion() string {
+	if c.yaml == nil {
+		return ""
+	}
+	return c.yaml.KubeVersion
+}
+
 // Path returns the chart's directory path
 func (c *Chart) Path() string {
 	return c.path
@@ -242,8 +295,13 @@ func NewChart(chartPath string) (*Chart, error) {
 
 type Testing struct {
 	config                   config.Configuration
+	out                      io.Writer
+	resultsOut               io.Writer
+	procExec                 *exec.ProcessExecutor
 	helm                     Helm
 	kubectl                  Kubectl
+	kubeClient               func() (kubernetes.Interface, error)
+	kubeContext              string
 	git                      Git
 	linter                   Linter
 	cmdExecutor              CmdExecutor
@@ -252,33 +310,184 @@ type Testing struct {
 	utils                    Utils
 	previousRevisionWorktree string
 	loadRules                func(string) (*helmignore.Rules, error)
+	listOCITags              func(string) ([]string, error)
+	// ctx is the context external tools are bound to, which is canceled once the chart has exceeded its timeout or
+	// the run has been interrupted, cleanupCtx the context tools cleaning up after a chart are bound to
+	ctx        context.Context
+	cleanupCtx context.Context
 }
 
-// TestResult holds test results for a specific chart
+// Names of the steps recorded in a TestResult
+const (
+	StepVersionCheck      = "version-check"
+	StepChartSchema       = "chart-schema"
+	StepYamlLint          = "yaml-lint"
+	StepValuesSchema      = "values-schema"
+	StepKubeAPIs          = "kube-apis"
+	StepManifestSchema    = "manifest-schema"
+	StepSnapshot          = "snapshot"
+	StepMaintainers       = "maintainers"
+	StepAdditionalCommand = "additional-command"
+	StepHelmLint          = "helm-lint"
+	StepInstall           = "install"
+	StepWait              = "wait"
+	StepHelmTest          = "helm-test"
+	StepUpgradePrevious   = "upgrade-previous"
+	StepUpgradeCurrent    = "upgrade-current"
+	StepUpgradeReleased   = "upgrade-released"
+	StepRollback          = "rollback"
+)
+
+// TestResult holds test results for a specific chart. Steps holds the results of the steps run for the chart in
+// the order they were run, Error is the error of the step which failed the chart. Skipped is set for charts which
+// have not been processed because they are deprecated or, in which case Excluded is set as well, configured to be
+// excluded. TimedOut is set for charts which failed because '--chart-timeout' or '--total-timeout' was exceeded.
+// Quarantined is set for failed charts whose failures are all quarantined with '--quarantine', which do not fail the
+// run.
 type TestResult struct {
-	Chart *Chart
-	Error error
+	Chart       *Chart
+	KubeContext string
+	Error       error
+	Steps       []StepResult
+	Output      string
+	Skipped     bool
+	Excluded    bool
+	TimedOut    bool
+	Quarantined bool
 }
 
-// NewTesting creates a new Testing struct with the given config.
-func NewTesting(config config.Configuration) (Testing, error) {
-	procExec := exec.NewProcessExecutor(config.Debug)
-	helmExtraArgs := strings.Fields(config.HelmExtraArgs)
-	helmExtraSetArgs := strings.Fields(config.HelmExtraSetArgs)
-	helmLintExtraArgs := strings.Fields(config.HelmLintExtraArgs)
+// timeoutError is the cause of the cancellation of charts which exceeded the timeout configured with the named option.
+type timeoutError struct {
+	option  string
+	timeout time.Duration
+}
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("exceeded %s of %s", e.option, e.timeout)
+}
+
+// displayName returns the chart of the result followed by the kube context it has been tested against, if any.
+func (r TestResult) displayName() string {
+	name := r.Chart.String()
+	if r.KubeContext != "" {
+		name = fmt.Sprintf("%s @ %s", name, r.KubeContext)
+	}
+	switch {
+	case r.Quarantined:
+		name += " (quarantined)"
+	case r.Flaky():
+		name += " (flaky)"
+	}
+	return name
+}
+
+// Flaky returns whether the chart passed, with some of its steps passing only after a retry.
+func (r TestResult) Flaky() bool {
+	return r.Error == nil && slices.ContainsFunc(r.Steps, func(s StepResult) bool { return s.Flaky })
+}
+
+// StepStatus is the outcome of a step.
+type StepStatus string
+
+const (
+	StatusPassed  StepStatus = "passed"
+	StatusFailed  StepStatus = "failed"
+	StatusSkipped StepStatus = "skipped"
+)
+
+// StepResult holds the result of a single step run for a chart. ValuesFile is empty if the step
+// does not use a CI values file. UpgradeFrom holds the versions an upgrade step upgraded from. Flaky is set
+// for steps which passed only after a retry.
+type StepResult struct {
+	Name        string
+	ValuesFile  string
+	UpgradeFrom string
+	StartTime   time.Time
+	Duration    time.Duration
+	Status      StepStatus
+	Error       error
+	Flaky       bool
+}
+
+var statusSymbols = map[StepStatus]string{
+	StatusPassed:  "✔︎",
+	StatusFailed:  "✖︎",
+	StatusSkipped: "-",
+}
+
+// displayName returns the name of the step followed by the base name of its values file and the versions it
+// upgraded from, if any.
+func (s StepResult) displayName() string {
+	name := s.Name
+	if s.ValuesFile != "" {
+		name = fmt.Sprintf("%s [%s]", name, filepath.Base(s.ValuesFile))
+	}
+	if s.UpgradeFrom != "" {
+		name = fmt.Sprintf("%s from %s", name, s.UpgradeFrom)
+	}
+	return name
+}
+
+// errStepSkipped is returned by steps which decided not to run, e.g. because a precondition is not met.
+var errStepSkipped = errors.New("step skipped")
+
+// runStep runs step and records its result. If step fails, the error is set as the result's error. If step
+// returns errStepSkipped, the step is recorded as skipped and nil is returned.
+func (r *TestResult) runStep(name string, valuesFile string, step func() error) error {
+	start := time.Now()
+	err := step()
+	stepResult := StepResult{
+		Name:       name,
+		ValuesFile: valuesFile,
+		StartTime:  start,
+		Duration:   time.Since(start),
+		Status:     StatusPassed,
+		Error:      err,
+	}
+	if errors.Is(err, errStepSkipped) {
+		stepResult.Status = StatusSkipped
+		stepResult.Error = nil
+		err = nil
+	} else if err != nil {
+		stepResult.Status = StatusFailed
+		r.Error = err
+	}
+	r.Steps = append(r.Steps, stepResult)
+	return err
+}
 
+// markFlaky marks the step which has just been recorded as flaky if flaky is set and the step passed.
+func (r *TestResult) markFlaky(flaky bool) {
+	if step := &r.Steps[len(r.Steps)-1]; flaky && step.Status == StatusPassed {
+		step.Flaky = true
+	}
+}
+
+// skipStep records a step which has not been run.
+func (r *TestResult) skipStep(name string, valuesFile string) {
+	r.runStep(name, valuesFile, func() error { return errStepSkipped }) // nolint: errcheck
+}
+
+// NewTesting creates a new Testing struct with the given config. With '--output json', stdout is reserved for the
+// results, so everything else, including the output of external tools, is written to stderr.
+func NewTesting(config config.Configuration) (Testing, error) {
 	testing := Testing{
 		config:           config,
-		helm:             tool.NewHelm(procExec, helmExtraArgs, helmLintExtraArgs, helmExtraSetArgs),
-		git:              tool.NewGit(procExec),
-		kubectl:          tool.NewKubectl(procExec, config.KubectlTimeout),
-		linter:           tool.NewLinter(procExec),
-		cmdExecutor:      tool.NewCmdTemplateExecutor(procExec),
+		out:              os.Stdout,
+		resultsOut:       os.Stdout,
 		accountValidator: tool.AccountValidator{},
 		directoryLister:  util.DirectoryLister{},
 		utils:            util.Utils{},
 		loadRules:        ignore.LoadRules,
+		listOCITags:      tool.ListOCITags,
+		kubeClient:       newKubeClient(""),
 	}
+	procExec := exec.NewProcessExecutor(config.Debug)
+	if config.Output == "json" {
+		testing.out = os.Stderr
+		procExec = procExec.WithOutput(os.Stderr)
+	}
+	testing.initTools(procExec)
 
 	versionString, err := testing.helm.Version()
 	if err != nil {
@@ -296,22 +505,154 @@ func NewTesting(config config.Configuration) (Testing, error) {
 	return testing, nil
 }
 
+// initTools creates the wrappers for the external tools using the given ProcessExecutor.
+func (t *Testing) initTools(procExec exec.ProcessExecutor) {
+	helmExtraArgs := strings.Fields(t.config.HelmExtraArgs)
+	helmExtraSetArgs := strings.Fields(t.config.HelmExtraSetArgs)
+	helmLintExtraArgs := strings.Fields(t.config.HelmLintExtraArgs)
+
+	procExec = procExec.WithContext(t.context())
+	t.procExec = &procExec
+	if t.config.HelmClient == "sdk" {
+		t.helm = tool.NewHelmSDK(procExec.Output(), helmExtraArgs, helmLintExtraArgs, helmExtraSetArgs).
+			WithKubeContext(t.kubeContext).WithContext(t.context())
+	} else {
+		t.helm = tool.NewHelm(procExec, helmExtraArgs, helmLintExtraArgs, helmExtraSetArgs).WithKubeContext(t.kubeContext)
+	}
+	t.git = tool.NewGit(procExec)
+	if t.config.KubernetesClient == "client-go" {
+		t.kubectl = tool.NewClientGoKubectl(t.kubeClient, procExec.Output(), t.config.KubectlTimeout, t.config.ReadinessTimeout).
+			WithDefaultNamespace(newKubeconfigNamespace(t.kubeContext)).WithContext(t.context())
+	} else {
+		t.kubectl = tool.NewKubectl(procExec, t.config.KubectlTimeout, t.config.ReadinessTimeout).WithKubeContext(t.kubeContext)
+	}
+	if t.config.YamlLinter == "native" {
+		t.linter = tool.NewNativeLinter(procExec)
+	} else {
+		t.linter = tool.NewLinter(procExec)
+	}
+	t.cmdExecutor = tool.NewCmdTemplateExecutor(procExec)
+}
+
+// withOutput returns a copy of t that writes its output, including the output of external tools, to w.
+func (t *Testing) withOutput(w io.Writer) *Testing {
+	clone := *t
+	clone.out = w
+	if t.procExec != nil {
+		clone.initTools(t.procExec.WithOutput(w))
+	}
+	return &clone
+}
+
+// withConfig returns a copy of t that uses cfg, e.g. the configuration of a chart with chart-specific overrides.
+func (t *Testing) withConfig(cfg config.Configuration) *Testing {
+	clone := *t
+	clone.config = cfg
+	if t.procExec != nil {
+		clone.initTools(*t.procExec)
+	}
+	return &clone
+}
+
+// withKubeContext returns a copy of t that runs Helm and kubectl against the given context of the kubeconfig.
+func (t *Testing) withKubeContext(kubeContext string) *Testing {
+	clone := *t
+	clone.kubeContext = kubeContext
+	clone.kubeClient = newKubeClient(kubeContext)
+	if t.procExec != nil {
+		clone.initTools(*t.procExec)
+	}
+	return &clone
+}
+
+// withContext returns a copy of t whose external tools are canceled once ctx is done.
+func (t *Testing) withContext(ctx context.Context) *Testing {
+	clone := *t
+	clone.ctx = ctx
+	if t.procExec != nil {
+		clone.initTools(*t.procExec)
+	}
+	return &clone
+}
+
+// WithCancellation returns a copy of t which stops processing charts and kills the external tools it runs once ctx
+// is done. Releases, namespaces, and worktrees are cleaned up by tools bound to cleanupCtx instead, so cleaning up
+// can be bounded separately.
+func (t *Testing) WithCancellation(ctx context.Context, cleanupCtx context.Context) *Testing {
+	clone := t.withContext(ctx)
+	clone.cleanupCtx = cleanupCtx
+	return clone
+}
+
+// uncanceled returns a copy of t whose external tools are not canceled along with t but only with the cleanup
+// context, e.g. to clean up after a chart which exceeded its timeout.
+func (t *Testing) uncanceled() *Testing {
+	if t.cleanupCtx != nil {
+		return t.withContext(t.cleanupCtx)
+	}
+	return t.withContext(context.WithoutCancel(t.context()))
+}
+
+func (t *Testing) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// forKubeContexts returns a copy of t per context configured with '--kube-contexts' or t itself if no contexts are
+// configured.
+func (t *Testing) forKubeContexts() []*Testing {
+	if len(t.config.KubeContexts) == 0 {
+		return []*Testing{t}
+	}
+	testings := make([]*Testing, 0, len(t.config.KubeContexts))
+	for _, kubeContext := range t.config.KubeContexts {
+		testings = append(testings, t.withKubeContext(kubeContext))
+	}
+	return testings
+}
+
+// newKubeClient returns a function creating a Kubernetes client for kubeContext on first use.
+func newKubeClient(kubeContext string) func() (kubernetes.Interface, error) {
+	return sync.OnceValues(func() (kubernetes.Interface, error) {
+		return tool.NewKubeClient(kubeContext)
+	})
+}
+
+// newKubeconfigNamespace returns a function loading the namespace of kubeContext from the kubeconfig on first use.
+func newKubeconfigNamespace(kubeContext string) func() (string, error) {
+	return sync.OnceValues(func() (string, error) {
+		return tool.KubeconfigNamespace(kubeContext)
+	})
+}
+
 // computePreviousRevisionPath converts any file or directory path to the same path in the
 // previous revision's working tree.
 func (t *Testing) computePreviousRevisionPath(fileOrDirPath string) string {
 	return filepath.Join(t.previousRevisionWorktree, fileOrDirPath)
 }
 
-func (t *Testing) processCharts(action func(chart *Chart) TestResult) ([]TestResult, error) {
+func (t *Testing) processCharts(action func(t *Testing, chart *Chart) TestResult) ([]TestResult, error) {
 	var results []TestResult // nolint: prealloc
-	chartDirs, err := t.FindChartDirsToBeProcessed()
+	chartDirs, excludedChartDirs, err := t.findChartDirsToBeProcessed()
 	if err != nil {
 		return nil, fmt.Errorf("failed identifying charts to process: %w", err)
-	} else if len(chartDirs) == 0 {
+	} else if len(chartDirs) == 0 && len(excludedChartDirs) == 0 {
 		return results, nil
 	}
 
+	// Results of charts which are not processed are appended to the results of the processed charts
+	var skippedResults []TestResult
+	for _, dir := range excludedChartDirs {
+		if chart, err := NewChart(dir); err == nil {
+			skippedResults = append(skippedResults, TestResult{Chart: chart, Skipped: true, Excluded: true})
+		}
+	}
+
 	var charts []*Chart
+	// Holds a Testing per chart which uses the chart's configuration
+	var chartTestings []*Testing
 	for _, dir := range chartDirs {
 		chart, err := NewChart(dir)
 		if err != nil {
@@ -319,28 +660,35 @@ func (t *Testing) processCharts(action func(chart *Chart) TestResult) ([]TestRes
 		}
 
 		if t.config.ExcludeDeprecated && chart.yaml.Deprecated {
-			fmt.Printf("Chart %q is deprecated and will be ignored because '--exclude-deprecated' is set\n", chart.String())
-		} else {
-			charts = append(charts, chart)
+			fmt.Fprintf(t.out, "Chart %q is deprecated and will be ignored because '--exclude-deprecated' is set\n", chart.String())
+			skippedResults = append(skippedResults, TestResult{Chart: chart, Skipped: true})
+			continue
 		}
+
+		chartConfig, err := config.LoadChartConfiguration(t.config, dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading configuration for chart %q: %w", chart, err)
+		}
+		charts = append(charts, chart)
+		chartTestings = append(chartTestings, t.withConfig(*chartConfig))
 	}
 
 	if !t.config.GithubGroups {
-		fmt.Println()
-		util.PrintDelimiterLineToWriter(os.Stdout, "-")
-		fmt.Println(" Charts to be processed:")
-		util.PrintDelimiterLineToWriter(os.Stdout, "-")
+		fmt.Fprintln(t.out)
+		util.PrintDelimiterLineToWriter(t.out, "-")
+		fmt.Fprintln(t.out, " Charts to be processed:")
+		util.PrintDelimiterLineToWriter(t.out, "-")
 	} else {
-		util.GithubGroupsBegin(os.Stdout, "Charts to be processed")
+		util.GithubGroupsBegin(t.out, "Charts to be processed")
 	}
 	for _, chart := range charts {
-		fmt.Printf(" %s\n", chart)
+		fmt.Fprintf(t.out, " %s\n", chart)
 	}
 	if !t.config.GithubGroups {
-		util.PrintDelimiterLineToWriter(os.Stdout, "-")
-		fmt.Println()
+		util.PrintDelimiterLineToWriter(t.out, "-")
+		fmt.Fprintln(t.out)
 	} else {
-		util.GithubGroupsEnd(os.Stdout)
+		util.GithubGroupsEnd(t.out)
 	}
 
 	repoArgs := map[string][]string{}
@@ -365,8 +713,18 @@ func (t *Testing) processCharts(action func(chart *Chart) TestResult) ([]TestRes
 
 	overallSuccess := true
 
+	ctx := t.context()
+	if t.config.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, t.config.TotalTimeout, timeoutError{"total timeout", t.config.TotalTimeout})
+		defer cancel()
+	}
+
 	// Checkout previous chart revisions and build their dependencies
-	if t.config.Upgrade {
+	usesMergeBase := slices.ContainsFunc(chartTestings, func(ct *Testing) bool {
+		return slices.Contains(ct.upgradeSources(), upgradeSourceMergeBase)
+	})
+	if t.config.Upgrade && usesMergeBase {
 		mergeBase, err := t.computeMergeBase()
 		if err != nil {
 			return results, fmt.Errorf("failed identifying merge base: %w", err)
@@ -377,35 +735,72 @@ func (t *Testing) processCharts(action func(chart *Chart) TestResult) ([]TestRes
 			return results, fmt.Errorf("could not create previous revision directory: %w", err)
 		}
 		t.previousRevisionWorktree = worktreePath
+		for _, ct := range chartTestings {
+			ct.previousRevisionWorktree = worktreePath
+		}
 		err = t.git.AddWorktree(worktreePath, mergeBase)
 		if err != nil {
 			return results, fmt.Errorf("could not create worktree for previous revision: %w", err)
 		}
-		defer t.git.RemoveWorktree(worktreePath) // nolint: errcheck
+		defer t.uncanceled().git.RemoveWorktree(worktreePath) // nolint: errcheck
 
-		if !t.config.SkipHelmDependencies {
-			for _, chart := range charts {
-				if err := t.helm.BuildDependenciesWithArgs(t.computePreviousRevisionPath(chart.Path()), t.config.HelmDependencyExtraArgs); err != nil {
+		for i, chart := range charts {
+			ct := chartTestings[i]
+			if !ct.config.SkipHelmDependencies {
+				if err := ct.helm.BuildDependenciesWithArgs(t.computePreviousRevisionPath(chart.Path()), ct.config.HelmDependencyExtraArgs); err != nil {
 					// Only print error (don't exit) if building dependencies for previous revision fails.
-					fmt.Printf("failed building dependencies for previous revision of chart %q: %v\n", chart, err.Error())
+					fmt.Fprintf(t.out, "failed building dependencies for previous revision of chart %q: %v\n", chart, err.Error())
 				}
 			}
 		}
 	}
 
-	for _, chart := range charts {
-		if !t.config.SkipHelmDependencies {
-			if err := t.helm.BuildDependenciesWithArgs(chart.Path(), t.config.HelmDependencyExtraArgs); err != nil {
-				return nil, fmt.Errorf("failed building dependencies for chart %q: %w", chart, err)
+	if t.config.Parallelism > 1 {
+		// Dependencies are built up front because 'helm dependency build' is not safe to run
+		// concurrently for charts sharing the same repository cache.
+		for i, chart := range charts {
+			ct := chartTestings[i]
+			if !ct.config.SkipHelmDependencies {
+				if err := ct.helm.BuildDependenciesWithArgs(chart.Path(), ct.config.HelmDependencyExtraArgs); err != nil {
+					return nil, fmt.Errorf("failed building dependencies for chart %q: %w", chart, err)
+				}
+			}
+		}
+		// Each chart is processed once per kube context
+		var contextCharts []*Chart
+		var contextTestings []*Testing
+		for i, chart := range charts {
+			for _, ct := range chartTestings[i].forKubeContexts() {
+				contextCharts = append(contextCharts, chart)
+				contextTestings = append(contextTestings, ct)
+			}
+		}
+		results = t.processChartsInParallel(ctx, contextCharts, contextTestings, action)
+	} else {
+		for i, chart := range charts {
+			ct := chartTestings[i]
+			// Once canceled, charts are not started anymore, which runChart records
+			if !ct.config.SkipHelmDependencies && ctx.Err() == nil {
+				if err := ct.helm.BuildDependenciesWithArgs(chart.Path(), ct.config.HelmDependencyExtraArgs); err != nil {
+					return nil, fmt.Errorf("failed building dependencies for chart %q: %w", chart, err)
+				}
+			}
+			for _, ct := range ct.forKubeContexts() {
+				var buf bytes.Buffer
+				result := ct.withOutput(io.MultiWriter(t.out, &buf)).runChart(ctx, chart, action)
+				result.Output = buf.String()
+				result.KubeContext = ct.kubeContext
+				results = append(results, result)
 			}
 		}
+	}
 
-		result := action(chart)
-		if result.Error != nil {
+	for _, result := range results {
+		if result.Error != nil && !result.Quarantined {
 			overallSuccess = false
 		}
-		results = append(results, result)
 	}
+	results = append(results, skippedResults...)
 	if overallSuccess {
 		return results, nil
 	}
@@ -413,57 +808,191 @@ func (t *Testing) processCharts(action func(chart *Chart) TestResult) ([]TestRes
 	return results, fmt.Errorf("failed processing charts")
 }
 
+// processChartsInParallel runs action for the given charts, each with its corresponding Testing from chartTestings,
+// using a pool of workers. The output of each chart is buffered and written as one block once the chart has been
+// processed, so output of different charts does not interleave. Results are returned in the order of charts.
+func (t *Testing) processChartsInParallel(ctx context.Context, charts []*Chart, chartTestings []*Testing, action func(t *Testing, chart *Chart) TestResult) []TestResult {
+	results := make([]TestResult, len(charts))
+	indexes := make(chan int)
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for range min(t.config.Parallelism, len(charts)) {
+		wg.Go(func() {
+			for i := range indexes {
+				var buf bytes.Buffer
+				results[i] = chartTestings[i].withOutput(&buf).runChart(ctx, charts[i], action)
+				results[i].Output = buf.String()
+				results[i].KubeContext = chartTestings[i].kubeContext
+
+				mutex.Lock()
+				buf.WriteTo(t.out) // nolint: errcheck
+				mutex.Unlock()
+			}
+		})
+	}
+
+	for i := range charts {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// runChart runs action for chart. The external tools run by action are canceled once ctx is done or the chart has
+// exceeded '--chart-timeout', in which case the chart fails with a timeout. Charts are not started at all once ctx is
+// done.
+func (t *Testing) runChart(ctx context.Context, chart *Chart, action func(t *Testing, chart *Chart) TestResult) TestResult {
+	if cause := context.Cause(ctx); cause != nil {
+		fmt.Fprintf(t.out, "Skipping chart %q because: %v\n", chart, cause)
+		return TestResult{Chart: chart, Error: cause, TimedOut: isTimeout(cause)}
+	}
+	if t.config.ChartTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, t.config.ChartTimeout, timeoutError{"chart timeout", t.config.ChartTimeout})
+		defer cancel()
+	}
+
+	result := action(t.withContext(ctx), chart)
+	if cause := context.Cause(ctx); cause != nil && result.Error != nil {
+		result.TimedOut = isTimeout(cause)
+	}
+	result.Quarantined = result.Error != nil && t.allFailuresQuarantined(result)
+	return result
+}
+
+// isQuarantined returns whether failures of the chart with the given values file are quarantined with '--quarantine',
+// which lists charts by name or path and CI values files by path.
+func (t *Testing) isQuarantined(chart *Chart, valuesFile string) bool {
+	return slices.ContainsFunc(t.config.Quarantine, func(entry string) bool {
+		entry = filepath.Clean(entry)
+		return entry == chart.Yaml().Name || entry == filepath.Clean(chart.Path()) ||
+			(valuesFile != "" && entry == filepath.Clean(valuesFile))
+	})
+}
+
+// allFailuresQuarantined returns whether all failed steps of result are quarantined.
+func (t *Testing) allFailuresQuarantined(result TestResult) bool {
+	failed := false
+	for _, step := range result.Steps {
+		if step.Status != StatusFailed {
+			continue
+		}
+		if !t.isQuarantined(result.Chart, step.ValuesFile) {
+			return false
+		}
+		failed = true
+	}
+	// Charts which failed before any step has been run can only be quarantined as a whole
+	return failed || t.isQuarantined(result.Chart, "")
+}
+
+func isTimeout(err error) bool {
+	var timeout timeoutError
+	return errors.As(err, &timeout)
+}
+
 // LintCharts lints charts (changed, all, specific) depending on the configuration.
 func (t *Testing) LintCharts() ([]TestResult, error) {
-	return t.processCharts(t.LintChart)
+	// Linting doesn't talk to a cluster, so charts are linted once regardless of '--kube-contexts'
+	cfg := t.config
+	cfg.KubeContexts = nil
+	return t.withConfig(cfg).processCharts((*Testing).LintChart)
+}
+
+// ValidateCharts validates the manifests rendered from charts (changed, all, specific) depending on the configuration
+// against JSON schemas.
+func (t *Testing) ValidateCharts() ([]TestResult, error) {
+	cfg := t.config
+	cfg.KubeContexts = nil
+	return t.withConfig(cfg).processCharts((*Testing).ValidateChart)
 }
 
 // InstallCharts install charts (changed, all, specific) depending on the configuration.
 func (t *Testing) InstallCharts() ([]TestResult, error) {
-	return t.processCharts(t.InstallChart)
+	return t.processCharts((*Testing).InstallChart)
 }
 
 // LintAndInstallCharts first lints and then installs charts (changed, all, specific) depending on the configuration.
 func (t *Testing) LintAndInstallCharts() ([]TestResult, error) {
-	return t.processCharts(t.LintAndInstallChart)
+	return t.processCharts((*Testing).LintAndInstallChart)
 }
 
-// PrintResults writes test results to stdout.
+// PrintResults writes test results to stdout, listing the steps of each chart with their status and duration. If
+// '--output json' is set, the results are written as JSON document, which is the only output on stdout.
 func (t *Testing) PrintResults(results []TestResult) {
+	if t.config.Output == "json" {
+		if err := writeJSONResults(t.resultsOut, results); err != nil {
+			fmt.Fprintln(os.Stderr, "Error printing results:", err)
+		}
+		return
+	}
+
 	if !t.config.GithubGroups {
-		fmt.Println()
-		util.PrintDelimiterLineToWriter(os.Stdout, "-")
+		fmt.Fprintln(t.out)
+		util.PrintDelimiterLineToWriter(t.out, "-")
 	} else {
-		util.GithubGroupsBegin(os.Stdout, "Test Results")
+		util.GithubGroupsBegin(t.out, "Test Results")
 	}
 	if results != nil {
 		for _, result := range results {
 			err := result.Error
-			if err != nil {
-				fmt.Printf(" %s %s > %s\n", "✖︎", result.Chart, err)
+			if result.Skipped {
+				fmt.Fprintf(t.out, " %s %s > skipped\n", "-", result.displayName())
+				continue
+			} else if err != nil {
+				fmt.Fprintf(t.out, " %s %s > %s\n", "✖︎", result.displayName(), err)
 			} else {
-				fmt.Printf(" %s %s\n", "✔︎", result.Chart)
+				fmt.Fprintf(t.out, " %s %s\n", "✔︎", result.displayName())
+			}
+			for _, step := range result.Steps {
+				fmt.Fprintf(t.out, "     %s %s (%s)", statusSymbols[step.Status], step.displayName(), step.Duration.Round(time.Millisecond))
+				if step.Error != nil {
+					fmt.Fprintf(t.out, " > %s", step.Error)
+				} else if step.Flaky {
+					fmt.Fprint(t.out, " > passed after retry")
+				}
+				fmt.Fprintln(t.out)
 			}
 		}
 	} else {
-		fmt.Println("No chart changes detected.")
+		fmt.Fprintln(t.out, "No chart changes detected.")
 	}
 	if !t.config.GithubGroups {
-		util.PrintDelimiterLineToWriter(os.Stdout, "-")
+		util.PrintDelimiterLineToWriter(t.out, "-")
 	} else {
-		util.GithubGroupsEnd(os.Stdout)
+		util.GithubGroupsEnd(t.out)
 	}
 }
 
-// LintChart lints the specified chart.
-func (t *Testing) LintChart(chart *Chart) TestResult {
-	fmt.Printf("Linting chart %q\n", chart)
+// LintChart lints the specified chart. Linting stops at the first failing check unless '--keep-going' is set, in
+// which case all checks are run and their errors are aggregated.
+func (t *Testing) LintChart(chart *Chart) (result TestResult) {
+	fmt.Fprintf(t.out, "Linting chart %q\n", chart)
 
-	result := TestResult{Chart: chart}
+	result = TestResult{Chart: chart}
+
+	var errs *multierror.Error
+	// failed records err and reports whether linting must stop
+	failed := func(err error) bool {
+		if err == nil {
+			return false
+		}
+		errs = multierror.Append(errs, err)
+		return !t.config.KeepGoing
+	}
+	defer func() {
+		if t.config.KeepGoing && errs != nil {
+			result.Error = errs.ErrorOrNil()
+		}
+	}()
 
 	if t.config.CheckVersionIncrement {
-		if err := t.CheckVersionIncrement(chart); err != nil {
-			result.Error = err
+		if failed(result.runStep(StepVersionCheck, "", func() error {
+			return t.CheckVersionIncrement(chart)
+		})) {
 			return result
 		}
 	}
@@ -473,47 +1002,95 @@ func (t *Testing) LintChart(chart *Chart) TestResult {
 	valuesFiles := chart.ValuesFilePathsForCI()
 
 	if t.config.ValidateChartSchema {
-		if err := t.linter.Yamale(chartYaml, t.config.ChartYamlSchema); err != nil {
-			result.Error = err
+		if failed(result.runStep(StepChartSchema, "", func() error {
+			return t.linter.Yamale(chartYaml, t.config.ChartYamlSchema)
+		})) {
 			return result
 		}
 	}
 
 	if t.config.ValidateYaml {
 		yamlFiles := append([]string{chartYaml, valuesYaml}, valuesFiles...)
-		for _, yamlFile := range yamlFiles {
-			if err := t.linter.YamlLint(yamlFile, t.config.LintConf); err != nil {
-				result.Error = err
+		if failed(result.runStep(StepYamlLint, "", func() error {
+			var yamlErrs *multierror.Error
+			for _, yamlFile := range yamlFiles {
+				if err := t.linter.YamlLint(yamlFile, t.config.LintConf); err != nil {
+					if !t.config.KeepGoing {
+						return err
+					}
+					yamlErrs = multierror.Append(yamlErrs, err)
+				}
+			}
+			return yamlErrs.ErrorOrNil()
+		})) {
+			return result
+		}
+	}
+
+	if t.config.ValidateValuesSchema && chart.HasValuesSchema() {
+		// The defaults are validated as well, since values files only override them
+		for _, valuesFile := range append([]string{""}, valuesFiles...) {
+			if failed(result.runStep(StepValuesSchema, valuesFile, func() error {
+				return t.ValidateValuesSchema(chart, valuesFile)
+			})) {
+				return result
+			}
+		}
+	}
+
+	if chart.kubeVersion() != "" || len(t.config.KubeVersions) > 0 {
+		for _, valuesFile := range valuesFilesOrDefaults(valuesFiles) {
+			if failed(result.runStep(StepKubeAPIs, valuesFile, func() error {
+				return t.CheckKubeAPIs(chart, valuesFile)
+			})) {
+				return result
+			}
+		}
+	}
+
+	if t.config.SchemaDir != "" {
+		for _, valuesFile := range valuesFilesOrDefaults(valuesFiles) {
+			if failed(result.runStep(StepManifestSchema, valuesFile, func() error {
+				return t.ValidateManifests(chart, valuesFile)
+			})) {
+				return result
+			}
+		}
+	}
+
+	if t.config.UpdateSnapshots || chart.HasSnapshots() {
+		for _, valuesFile := range valuesFilesOrDefaults(valuesFiles) {
+			if failed(result.runStep(StepSnapshot, valuesFile, func() error {
+				return t.CheckSnapshot(chart, valuesFile)
+			})) {
 				return result
 			}
 		}
 	}
 
 	if t.config.ValidateMaintainers {
-		if err := t.ValidateMaintainers(chart); err != nil {
-			result.Error = err
+		if failed(result.runStep(StepMaintainers, "", func() error {
+			return t.ValidateMaintainers(chart)
+		})) {
 			return result
 		}
 	}
 
 	for _, cmd := range t.config.AdditionalCommands {
-		if err := t.cmdExecutor.RunCommand(cmd, chart); err != nil {
-			result.Error = err
+		if failed(result.runStep(StepAdditionalCommand, "", func() error {
+			return t.cmdExecutor.RunCommand(cmd, chart)
+		})) {
 			return result
 		}
 	}
 
-	// Lint with defaults if no values files are specified.
-	if len(valuesFiles) == 0 {
-		valuesFiles = append(valuesFiles, "")
-	}
-
-	for _, valuesFile := range valuesFiles {
+	for _, valuesFile := range valuesFilesOrDefaults(valuesFiles) {
 		if valuesFile != "" {
-			fmt.Printf("\nLinting chart with values file %q...\n\n", valuesFile)
+			fmt.Fprintf(t.out, "\nLinting chart with values file %q...\n\n", valuesFile)
 		}
-		if err := t.helm.LintWithValues(chart.Path(), valuesFile); err != nil {
-			result.Error = err
+		if failed(result.runStep(StepHelmLint, valuesFile, func() error {
+			return t.helm.LintWithValues(chart.Path(), valuesFile)
+		})) {
 			break
 		}
 	}
@@ -521,28 +1098,59 @@ func (t *Testing) LintChart(chart *Chart) TestResult {
 	return result
 }
 
+// ValidateChart renders the specified chart with each CI values file and validates the manifests against JSON
+// schemas. All values files are validated, so every invalid one is reported at once.
+func (t *Testing) ValidateChart(chart *Chart) TestResult {
+	fmt.Fprintf(t.out, "Validating chart %q\n", chart)
+
+	result := TestResult{Chart: chart}
+	var errs *multierror.Error
+	for _, valuesFile := range valuesFilesOrDefaults(chart.ValuesFilePathsForCI()) {
+		if err := result.runStep(StepManifestSchema, valuesFile, func() error {
+			return t.ValidateManifests(chart, valuesFile)
+		}); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	result.Error = errs.ErrorOrNil()
+	return result
+}
+
+// valuesName returns the name of a values file without extension, or "default" if valuesFile is empty.
+func valuesName(valuesFile string) string {
+	if valuesFile == "" {
+		return "default"
+	}
+	return strings.TrimSuffix(filepath.Base(valuesFile), filepath.Ext(valuesFile))
+}
+
+// valuesFilesOrDefaults returns valuesFiles or, if there are none, a single empty values file so the chart is tested
+// with its defaults.
+func valuesFilesOrDefaults(valuesFiles []string) []string {
+	if len(valuesFiles) == 0 {
+		return []string{""}
+	}
+	return valuesFiles
+}
+
 // InstallChart installs the specified chart into a new namespace, waits for resources to become ready, and eventually
 // uninstalls it and deletes the namespace again.
 func (t *Testing) InstallChart(chart *Chart) TestResult {
-	var result TestResult
+	result := TestResult{Chart: chart}
 
 	if t.config.Upgrade {
 		// Test upgrade from previous version
-		result = t.UpgradeChart(chart)
+		t.upgradeChart(&result, chart)
 		if result.Error != nil {
 			return result
 		}
 		// Test upgrade of current version (related: https://github.com/helm/chart-testing/issues/19)
-		if err := t.doUpgrade(chart, chart, true); err != nil {
-			result.Error = err
+		if err := t.doUpgrade(&result, chart, chart, true); err != nil {
 			return result
 		}
 	}
 
-	result = TestResult{Chart: chart}
-	if err := t.doInstall(chart); err != nil {
-		result.Error = err
-	}
+	t.doInstall(&result, chart) // nolint: errcheck
 
 	return result
 }
@@ -553,29 +1161,46 @@ func (t *Testing) InstallChart(chart *Chart) TestResult {
 // according to the SemVer specification, upgrade testing will be skipped.
 func (t *Testing) UpgradeChart(chart *Chart) TestResult {
 	result := TestResult{Chart: chart}
+	t.upgradeChart(&result, chart)
+	return result
+}
 
+func (t *Testing) upgradeChart(result *TestResult, chart *Chart) {
+	for _, source := range t.upgradeSources() {
+		if source == upgradeSourceMergeBase {
+			t.upgradeFromMergeBase(result, chart)
+		} else {
+			t.upgradeFromReleases(result, chart, source)
+		}
+	}
+}
+
+func (t *Testing) upgradeFromMergeBase(result *TestResult, chart *Chart) {
 	breakingChangeAllowed, err := t.checkBreakingChangeAllowed(chart)
 
 	if breakingChangeAllowed {
 		if err != nil {
-			fmt.Printf("Skipping upgrade test of %q because: %v\n", chart, err.Error())
+			fmt.Fprintf(t.out, "Skipping upgrade test of %q because: %v\n", chart, err.Error())
+			result.skipStep(StepUpgradePrevious, "")
 		}
-		return result
+		return
 	} else if err != nil {
-		fmt.Printf("Error comparing chart versions for %q\n", chart)
-		result.Error = err
-		return result
+		fmt.Fprintf(t.out, "Error comparing chart versions for %q\n", chart)
+		result.runStep(StepUpgradePrevious, "", func() error { return err }) // nolint: errcheck
+		return
 	}
 
 	if oldChart, err := NewChart(t.computePreviousRevisionPath(chart.Path())); err == nil {
-		result.Error = t.doUpgrade(oldChart, chart, false)
+		t.doUpgrade(result, oldChart, chart, false) // nolint: errcheck
 	}
-
-	return result
 }
 
-func (t *Testing) doInstall(chart *Chart) error {
-	fmt.Printf("Installing chart %q...\n", chart)
+func (t *Testing) doInstall(result *TestResult, chart *Chart) error {
+	if t.kubeContext != "" {
+		fmt.Fprintf(t.out, "Installing chart %q in kube context %q...\n", chart, t.kubeContext)
+	} else {
+		fmt.Fprintf(t.out, "Installing chart %q...\n", chart)
+	}
 	valuesFiles := chart.ValuesFilePathsForCI()
 
 	// Test with defaults if no values files are specified.
@@ -585,29 +1210,53 @@ func (t *Testing) doInstall(chart *Chart) error {
 
 	for _, valuesFile := range valuesFiles {
 		if valuesFile != "" {
-			fmt.Printf("\nInstalling chart with values file %q...\n\n", valuesFile)
+			fmt.Fprintf(t.out, "\nInstalling chart with values file %q...\n\n", valuesFile)
 		}
 
 		// Use anonymous function. Otherwise deferred calls would pile up
 		// and be executed in reverse order after the loop.
 		fun := func() error {
-			namespace, release, releaseSelector, cleanup := t.generateInstallConfig(chart)
+			namespace, release, releaseSelector, cleanup := t.generateInstallConfig(chart, valuesFile)
 			if !t.config.SkipCleanUp {
 				defer cleanup()
 			}
 
-			if t.config.Namespace == "" {
-				if err := t.kubectl.CreateNamespace(namespace); err != nil {
-					return err
+			if err := result.runStep(StepInstall, valuesFile, func() error {
+				if t.config.Namespace == "" {
+					if err := t.createNamespace(chart, namespace); err != nil {
+						return err
+					}
 				}
+				return t.helm.InstallWithValues(chart.Path(), valuesFile, namespace, release)
+			}); err != nil {
+				return err
 			}
-			if err := t.helm.InstallWithValues(chart.Path(), valuesFile, namespace, release); err != nil {
+			var flaky bool
+			err := result.runStep(StepWait, valuesFile, func() error {
+				return t.retry(&flaky, func() error {
+					return t.kubectl.WaitForResources(namespace, releaseSelector)
+				})
+			})
+			result.markFlaky(flaky)
+			if err != nil {
 				return err
 			}
-			return t.testRelease(namespace, release, releaseSelector)
+			flaky = false
+			err = result.runStep(StepHelmTest, valuesFile, func() error {
+				return t.retry(&flaky, func() error {
+					return t.helm.Test(namespace, release)
+				})
+			})
+			result.markFlaky(flaky)
+			return err
 		}
 
 		if err := fun(); err != nil {
+			// Failures of quarantined values files do not keep other values files from being tested
+			if t.isQuarantined(chart, valuesFile) {
+				fmt.Fprintf(t.out, "Continuing despite failure of quarantined values file %q: %v\n", valuesFile, err)
+				continue
+			}
 			return err
 		}
 	}
@@ -615,8 +1264,30 @@ func (t *Testing) doInstall(chart *Chart) error {
 	return nil
 }
 
-func (t *Testing) doUpgrade(oldChart, newChart *Chart, oldChartMustPass bool) error {
-	fmt.Printf("Testing upgrades of chart %q relative to previous revision %q...\n", newChart, oldChart)
+func (t *Testing) doUpgrade(result *TestResult, oldChart, newChart *Chart, oldChartMustPass bool) error {
+	step := StepUpgradePrevious
+	if oldChartMustPass {
+		step = StepUpgradeCurrent
+	}
+	return t.doUpgradeChain(result, step, []*Chart{oldChart}, newChart, oldChartMustPass)
+}
+
+// doUpgradeChain installs the first of oldCharts, upgrades the release to each of the other old charts in turn, and
+// eventually to newChart, testing the release after each upgrade. If '--rollback' is set, the release is then rolled
+// back to its previous revision and tested again. Each CI values file of the first old chart is tested in a release of
+// its own. Unless oldChartMustPass is set, a values file is skipped if installing or upgrading the old charts fails.
+func (t *Testing) doUpgradeChain(result *TestResult, step string, oldCharts []*Chart, newChart *Chart, oldChartMustPass bool) error {
+	oldChart := oldCharts[0]
+	from := upgradePath(oldCharts)
+	if len(oldCharts) == 1 {
+		fmt.Fprintf(t.out, "Testing upgrades of chart %q relative to previous revision %q...\n", newChart, oldChart)
+	} else {
+		fmt.Fprintf(t.out, "Testing upgrades of chart %q from versions %s...\n", newChart, from)
+	}
+	// recordFrom records the versions upgraded from in the step which has just been recorded
+	recordFrom := func() {
+		result.Steps[len(result.Steps)-1].UpgradeFrom = from
+	}
 	valuesFiles := oldChart.ValuesFilePathsForCI()
 	if len(valuesFiles) == 0 {
 		valuesFiles = append(valuesFiles, "")
@@ -624,46 +1295,85 @@ func (t *Testing) doUpgrade(oldChart, newChart *Chart, oldChartMustPass bool) er
 	for _, valuesFile := range valuesFiles {
 		if valuesFile != "" {
 			if t.config.SkipMissingValues && !newChart.HasCIValuesFile(valuesFile) {
-				fmt.Printf("Upgrade testing for values file %q skipped because a corresponding values file was not found in %s/ci\n", valuesFile, newChart.Path())
+				fmt.Fprintf(t.out, "Upgrade testing for values file %q skipped because a corresponding values file was not found in %s/ci\n", valuesFile, newChart.Path())
+				result.skipStep(step, valuesFile)
+				recordFrom()
 				continue
 			}
-			fmt.Printf("\nInstalling chart %q with values file %q...\n\n", oldChart, valuesFile)
+			fmt.Fprintf(t.out, "\nInstalling chart %q with values file %q...\n\n", oldChart, valuesFile)
 		}
 
 		// Use anonymous function. Otherwise deferred calls would pile up
 		// and be executed in reverse order after the loop.
 		fun := func() error {
-			namespace, release, releaseSelector, cleanup := t.generateInstallConfig(oldChart)
+			namespace, release, releaseSelector, cleanup := t.generateInstallConfig(oldChart, valuesFile)
 			if !t.config.SkipCleanUp {
 				defer cleanup()
 			}
 
-			if t.config.Namespace == "" {
-				if err := t.kubectl.CreateNamespace(namespace); err != nil {
-					return err
+			var flaky bool
+			err := result.runStep(step, valuesFile, func() error {
+				if t.config.Namespace == "" {
+					if err := t.createNamespace(oldChart, namespace); err != nil {
+						return err
+					}
 				}
-			}
-			// Install previous version of chart. If installation fails, ignore this release.
-			if err := t.helm.InstallWithValues(oldChart.Path(), valuesFile, namespace, release); err != nil {
-				if oldChartMustPass {
-					return err
+				// Install previous version of chart. If installation fails, ignore this release.
+				if err := t.helm.InstallWithValues(oldChart.Path(), valuesFile, namespace, release); err != nil {
+					if oldChartMustPass {
+						return err
+					}
+					fmt.Fprintf(t.out, "Upgrade testing for release %q skipped because of previous revision installation error: %v\n", release, err.Error())
+					return errStepSkipped
 				}
-				fmt.Printf("Upgrade testing for release %q skipped because of previous revision installation error: %v\n", release, err.Error())
-				return nil
-			}
-			if err := t.testRelease(namespace, release, releaseSelector); err != nil {
-				if oldChartMustPass {
+				if err := t.testRelease(namespace, release, releaseSelector, &flaky); err != nil {
+					if oldChartMustPass {
+						return err
+					}
+					fmt.Fprintf(t.out, "Upgrade testing for release %q skipped because of previous revision testing error: %v\n", release, err.Error())
+					return errStepSkipped
+				}
+
+				// Upgrade through the intermediate versions. If an upgrade fails, ignore this release.
+				for _, intermediateChart := range oldCharts[1:] {
+					fmt.Fprintf(t.out, "\nUpgrading release %q to chart %q...\n\n", release, intermediateChart)
+					err := t.helm.UpgradeWithValues(intermediateChart.Path(), valuesFile, namespace, release)
+					if err == nil {
+						err = t.testRelease(namespace, release, releaseSelector, &flaky)
+					}
+					if err != nil {
+						if oldChartMustPass {
+							return err
+						}
+						fmt.Fprintf(t.out, "Upgrade testing for release %q skipped because of upgrade error to previous version %s: %v\n", release, intermediateChart.Yaml().Version, err.Error())
+						return errStepSkipped
+					}
+				}
+
+				if err := t.helm.UpgradeWithValues(newChart.Path(), valuesFile, namespace, release); err != nil {
 					return err
 				}
-				fmt.Printf("Upgrade testing for release %q skipped because of previous revision testing error: %v\n", release, err.Error())
-				return nil
-			}
 
-			if err := t.helm.UpgradeWithValues(newChart.Path(), valuesFile, namespace, release); err != nil {
+				return t.testRelease(namespace, release, releaseSelector, &flaky)
+			})
+			recordFrom()
+			result.markFlaky(flaky)
+			if err != nil || !t.config.Rollback || result.Steps[len(result.Steps)-1].Status == StatusSkipped {
 				return err
 			}
 
-			return t.testRelease(namespace, release, releaseSelector)
+			// Roll back the upgrade before the release is cleaned up
+			flaky = false
+			err = result.runStep(StepRollback, valuesFile, func() error {
+				fmt.Fprintf(t.out, "\nRolling back release %q to its previous revision...\n\n", release)
+				if err := t.helm.Rollback(namespace, release); err != nil {
+					return err
+				}
+				return t.testRelease(namespace, release, releaseSelector, &flaky)
+			})
+			recordFrom()
+			result.markFlaky(flaky)
+			return err
 		}
 
 		if err := fun(); err != nil {
@@ -674,29 +1384,68 @@ func (t *Testing) doUpgrade(oldChart, newChart *Chart, oldChartMustPass bool) er
 	return nil
 }
 
-func (t *Testing) testRelease(namespace, release, releaseSelector string) error {
-	if err := t.kubectl.WaitForDeployments(namespace, releaseSelector); err != nil {
+// testRelease waits for the resources of the release to become ready and runs its tests, retrying both with retry.
+// flaky is set if either passed only after a retry.
+func (t *Testing) testRelease(namespace, release, releaseSelector string, flaky *bool) error {
+	if err := t.retry(flaky, func() error {
+		return t.kubectl.WaitForResources(namespace, releaseSelector)
+	}); err != nil {
 		return err
 	}
 
-	return t.helm.Test(namespace, release)
+	return t.retry(flaky, func() error {
+		return t.helm.Test(namespace, release)
+	})
 }
 
-func (t *Testing) generateInstallConfig(chart *Chart) (namespace, release, releaseSelector string, cleanup func()) {
+// retry runs fn and retries it up to '--retries' times while it fails, waiting '--retry-backoff' before the first
+// retry and twice as long before each further one. flaky is set if fn passed only after a retry.
+func (t *Testing) retry(flaky *bool, fn func() error) error {
+	backoff := t.config.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			*flaky = *flaky || attempt > 1
+			return nil
+		}
+		if attempt > t.config.Retries || t.context().Err() != nil {
+			return err
+		}
+
+		fmt.Fprintf(t.out, "Attempt %d of %d failed: %v\nRetrying in %s...\n", attempt, t.config.Retries+1, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-t.context().Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func (t *Testing) generateInstallConfig(chart *Chart, valuesFile string) (namespace, release, releaseSelector string, cleanup func()) {
 	if t.config.Namespace != "" {
 		namespace = t.config.Namespace
 		release, _ = chart.CreateInstallParams(t.config.BuildID, t.config.ReleaseName)
 		releaseSelector = fmt.Sprintf("%s=%s", t.config.ReleaseLabel, release)
 		cleanup = func() {
-			t.PrintEventsPodDetailsAndLogs(namespace, releaseSelector)
-			t.helm.DeleteRelease(namespace, release)
+			t := t.uncanceled()
+			t.reportDiagnostics(chart, valuesFile, namespace, release, releaseSelector)
+			if err := t.helm.DeleteRelease(namespace, release); err != nil {
+				fmt.Fprintln(t.out, "Error deleting Helm release:", err)
+			}
 		}
 	} else {
 		release, namespace = chart.CreateInstallParams(t.config.BuildID, t.config.ReleaseName)
 		cleanup = func() {
-			t.PrintEventsPodDetailsAndLogs(namespace, releaseSelector)
-			t.helm.DeleteRelease(namespace, release)
-			t.kubectl.DeleteNamespace(namespace)
+			// Diagnostics are collected and the release is deleted even if the chart exceeded its timeout
+			t := t.uncanceled()
+			t.reportDiagnostics(chart, valuesFile, namespace, release, releaseSelector)
+			if err := t.helm.DeleteRelease(namespace, release); err != nil {
+				fmt.Fprintln(t.out, "Error deleting Helm release:", err)
+			}
+			if err := t.kubectl.DeleteNamespace(namespace); err != nil {
+				fmt.Fprintln(t.out, "Error deleting namespace:", err)
+			}
 		}
 	}
 
@@ -709,19 +1458,28 @@ func (t *Testing) LintAndInstallChart(chart *Chart) TestResult {
 	if result.Error != nil {
 		return result
 	}
-	return t.InstallChart(chart)
+	installResult := t.InstallChart(chart)
+	installResult.Steps = append(result.Steps, installResult.Steps...)
+	return installResult
 }
 
 // FindChartDirsToBeProcessed identifies charts to be processed depending on the configuration
 // (changed charts, all charts, or specific charts).
 func (t *Testing) FindChartDirsToBeProcessed() ([]string, error) {
+	chartDirs, _, err := t.findChartDirsToBeProcessed()
+	return chartDirs, err
+}
+
+// findChartDirsToBeProcessed works like FindChartDirsToBeProcessed but additionally returns the directories of the
+// charts that would have been processed if they were not configured to be excluded.
+func (t *Testing) findChartDirsToBeProcessed() (chartDirs []string, excludedChartDirs []string, err error) {
 	cfg := t.config
 	if cfg.ProcessAllCharts {
-		return t.ReadAllChartDirectories()
+		return t.readAllChartDirectories()
 	} else if len(cfg.Charts) > 0 {
-		return t.config.Charts, nil
+		return t.config.Charts, nil, nil
 	}
-	return t.ComputeChangedChartDirectories()
+	return t.computeChangedChartDirectories()
 }
 
 func (t *Testing) computeMergeBase() (string, error) {
@@ -741,32 +1499,45 @@ func (t *Testing) computeMergeBase() (string, error) {
 // ComputeChangedChartDirectories takes the merge base of HEAD and the configured remote and target branch and computes a
 // slice of changed charts from that in the configured chart directories excluding those configured to be excluded.
 func (t *Testing) ComputeChangedChartDirectories() ([]string, error) {
+	changedChartDirs, _, err := t.computeChangedChartDirectories()
+	return changedChartDirs, err
+}
+
+func (t *Testing) computeChangedChartDirectories() (changedChartDirs []string, excludedChartDirs []string, err error) {
 	cfg := t.config
 
 	mergeBase, err := t.computeMergeBase()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	allChangedChartFiles, err := t.git.ListChangedFilesInDirs(mergeBase, cfg.ChartDirs...)
 	if err != nil {
-		return nil, fmt.Errorf("failed creating diff: %w", err)
+		return nil, nil, fmt.Errorf("failed creating diff: %w", err)
 	}
 
 	changedChartFiles := map[string][]string{}
+	excluded := map[string]bool{}
 	for _, file := range allChangedChartFiles {
 		pathElements := strings.SplitN(filepath.ToSlash(file), "/", 3)
-		if len(pathElements) < 2 || slices.Contains(cfg.ExcludedCharts, pathElements[1]) {
+		if len(pathElements) < 2 {
 			continue
 		}
 		dir := filepath.Dir(file)
 		// Make sure directory is really a chart directory
 		chartDir, err := t.utils.LookupChartDir(cfg.ChartDirs, dir)
+		if slices.Contains(cfg.ExcludedCharts, pathElements[1]) {
+			if err == nil {
+				excluded[chartDir] = true
+			}
+			continue
+		}
 		chartDirElement := strings.Split(chartDir, "/")
 		if err == nil {
 			if len(chartDirElement) > 1 {
 				chartDirName := chartDirElement[len(chartDirElement)-1]
 				if slices.Contains(cfg.ExcludedCharts, chartDirName) {
+					excluded[chartDir] = true
 					continue
 				}
 			}
@@ -776,16 +1547,18 @@ func (t *Testing) ComputeChangedChartDirectories() ([]string, error) {
 		}
 	}
 
-	changedChartDirs := []string{}
+	excludedChartDirs = slices.Sorted(maps.Keys(excluded))
+
+	changedChartDirs = []string{}
 	if t.config.UseHelmignore {
 		for chartDir, changedChartFiles := range changedChartFiles {
 			rules, err := t.loadRules(chartDir)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			filteredChartFiles, err := ignore.FilterFiles(changedChartFiles, rules)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			if len(filteredChartFiles) > 0 {
 				changedChartDirs = append(changedChartDirs, chartDir)
@@ -797,33 +1570,110 @@ func (t *Testing) ComputeChangedChartDirectories() ([]string, error) {
 		}
 	}
 
-	return changedChartDirs, nil
+	if cfg.IncludeDependents {
+		changedChartDirs, err = t.addDependentCharts(changedChartDirs)
+	}
+
+	return changedChartDirs, excludedChartDirs, err
+}
+
+// addDependentCharts adds all charts in the configured chart directories which depend on any of the given
+// charts, directly or transitively, via 'file://' dependencies. The reason for adding a chart is printed
+// to stderr.
+func (t *Testing) addDependentCharts(chartDirs []string) ([]string, error) {
+	allChartDirs, err := t.ReadAllChartDirectories()
+	if err != nil {
+		return nil, err
+	}
+
+	// Maps the absolute directory of a chart to the directories of the charts depending on it. Absolute paths are used
+	// as keys, so that relative and absolute 'file://' repositories refer to the same chart.
+	dependents := map[string][]string{}
+	for _, chartDir := range allChartDirs {
+		chartYaml, err := util.ReadChartYaml(chartDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading dependencies of chart %q: %w", chartDir, err)
+		}
+		for _, dependency := range chartYaml.Dependencies {
+			localPath, ok := strings.CutPrefix(dependency.Repository, "file://")
+			if !ok {
+				continue
+			}
+			if !filepath.IsAbs(localPath) {
+				localPath = filepath.Join(chartDir, localPath)
+			}
+			dependencyDir, err := filepath.Abs(localPath)
+			if err != nil {
+				return nil, err
+			}
+			dependents[dependencyDir] = append(dependents[dependencyDir], filepath.Clean(chartDir))
+		}
+	}
+
+	result := slices.Clone(chartDirs)
+	seen := map[string]bool{}
+	for _, chartDir := range chartDirs {
+		seen[filepath.Clean(chartDir)] = true
+	}
+
+	queue := slices.Clone(chartDirs)
+	for len(queue) > 0 {
+		chartDir := queue[0]
+		queue = queue[1:]
+		absChartDir, err := filepath.Abs(chartDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, dependent := range dependents[absChartDir] {
+			if seen[dependent] {
+				continue
+			}
+			seen[dependent] = true
+			fmt.Fprintf(os.Stderr, "Chart %q added because it depends on changed chart %q\n", dependent, chartDir)
+			result = append(result, dependent)
+			queue = append(queue, dependent)
+		}
+	}
+
+	return result, nil
 }
 
 // ReadAllChartDirectories returns a slice of all charts in the configured chart directories except those
 // configured to be excluded.
 func (t *Testing) ReadAllChartDirectories() ([]string, error) {
+	chartDirs, _, err := t.readAllChartDirectories()
+	return chartDirs, err
+}
+
+func (t *Testing) readAllChartDirectories() (chartDirs []string, excludedChartDirs []string, err error) {
 	cfg := t.config
 
-	var chartDirs []string
 	for _, chartParentDir := range cfg.ChartDirs {
+		var excluded []string
 		dirs, err := t.directoryLister.ListChildDirs(chartParentDir,
 			func(dir string) bool {
-				_, err := t.utils.LookupChartDir(cfg.ChartDirs, dir)
-				return err == nil && !slices.Contains(cfg.ExcludedCharts, filepath.Base(dir))
+				if _, err := t.utils.LookupChartDir(cfg.ChartDirs, dir); err != nil {
+					return false
+				}
+				if slices.Contains(cfg.ExcludedCharts, filepath.Base(dir)) {
+					excluded = append(excluded, dir)
+					return false
+				}
+				return true
 			})
 		if err != nil {
-			return nil, fmt.Errorf("failed reading chart directories: %w", err)
+			return nil, nil, fmt.Errorf("failed reading chart directories: %w", err)
 		}
 		chartDirs = append(chartDirs, dirs...)
+		excludedChartDirs = append(excludedChartDirs, excluded...)
 	}
 
-	return chartDirs, nil
+	return chartDirs, excludedChartDirs, nil
 }
 
 // CheckVersionIncrement checks that the new chart version is greater than the old one using semantic version comparison.
 func (t *Testing) CheckVersionIncrement(chart *Chart) error {
-	fmt.Printf("Checking chart %q for a version bump...\n", chart)
+	fmt.Fprintf(t.out, "Checking chart %q for a version bump...\n", chart)
 
 	oldVersion, err := t.GetOldChartVersion(chart.Path())
 	if err != nil {
@@ -834,11 +1684,11 @@ func (t *Testing) CheckVersionIncrement(chart *Chart) error {
 		return nil
 	}
 
-	fmt.Println("Old chart version:", oldVersion)
+	fmt.Fprintln(t.out, "Old chart version:", oldVersion)
 
 	chartYaml := chart.Yaml()
 	newVersion := chartYaml.Version
-	fmt.Println("New chart version:", newVersion)
+	fmt.Fprintln(t.out, "New chart version:", newVersion)
 
 	result, err := util.CompareVersions(oldVersion, newVersion)
 	if err != nil {
@@ -849,7 +1699,7 @@ func (t *Testing) CheckVersionIncrement(chart *Chart) error {
 		return errors.New("chart version not ok. Needs a version bump! ")
 	}
 
-	fmt.Println("Chart version ok.")
+	fmt.Fprintln(t.out, "Chart version ok.")
 	return nil
 }
 
@@ -874,7 +1724,7 @@ func (t *Testing) GetOldChartVersion(chartPath string) (string, error) {
 
 	chartYamlFile := filepath.Join(chartPath, "Chart.yaml")
 	if !t.git.FileExistsOnBranch(chartYamlFile, cfg.Remote, cfg.TargetBranch) {
-		fmt.Printf("Unable to find chart on %s. New chart detected.\n", cfg.TargetBranch)
+		fmt.Fprintf(t.out, "Unable to find chart on %s. New chart detected.\n", cfg.TargetBranch)
 		return "", nil
 	}
 
@@ -894,7 +1744,7 @@ func (t *Testing) GetOldChartVersion(chartPath string) (string, error) {
 // ValidateMaintainers validates maintainers in the Chart.yaml file. Maintainer names must be valid accounts
 // (GitHub, Bitbucket, GitLab) names. Deprecated charts must not have maintainers.
 func (t *Testing) ValidateMaintainers(chart *Chart) error {
-	fmt.Println("Validating maintainers...")
+	fmt.Fprintln(t.out, "Validating maintainers...")
 
 	chartYaml := chart.Yaml()
 
@@ -923,8 +1773,99 @@ func (t *Testing) ValidateMaintainers(chart *Chart) error {
 	return nil
 }
 
+// reportDiagnostics prints the diagnostics of a release or, if an artifacts directory is configured, writes them to
+// files organized per chart, values file, and release.
+func (t *Testing) reportDiagnostics(chart *Chart, valuesFile, namespace, release, selector string) {
+	if t.config.ArtifactsDir == "" {
+		t.PrintEventsPodDetailsAndLogs(namespace, selector)
+		return
+	}
+
+	dir := filepath.Join(t.config.ArtifactsDir, chart.Yaml().Name, valuesName(valuesFile), release)
+	if err := t.WriteEventsPodDetailsAndLogs(dir, namespace, release, selector); err != nil {
+		fmt.Fprintln(t.out, "Error writing diagnostics:", err)
+	}
+	fmt.Fprintf(t.out, "Diagnostics of release %q written to %s\n", release, dir)
+}
+
+// WriteEventsPodDetailsAndLogs writes the events of namespace, the rendered manifests of release, and the
+// descriptions and container logs of the pods matching selector to files in dir.
+func (t *Testing) WriteEventsPodDetailsAndLogs(dir, namespace, release, selector string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed creating artifacts directory: %w", err)
+	}
+
+	var errs *multierror.Error
+	errs = multierror.Append(errs, t.writeArtifact(dir, "events.txt", func(t *Testing) error {
+		return t.kubectl.GetEvents(namespace)
+	}))
+
+	manifest, err := t.helm.GetManifest(namespace, release)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte(manifest), 0644)
+	}
+	if err != nil {
+		errs = multierror.Append(errs, fmt.Errorf("failed writing manifest.yaml: %w", err))
+	}
+
+	pods, err := t.kubectl.GetPods(
+		"--no-headers",
+		"--namespace",
+		namespace,
+		"--selector",
+		selector,
+		"--output",
+		"jsonpath={.items[*].metadata.name}",
+	)
+	if err != nil {
+		return multierror.Append(errs, err).ErrorOrNil()
+	}
+
+	for _, pod := range pods {
+		pod = strings.Trim(pod, "'")
+		errs = multierror.Append(errs, t.writeArtifact(dir, fmt.Sprintf("describe-%s.txt", pod), func(t *Testing) error {
+			return t.kubectl.DescribePod(namespace, pod)
+		}))
+
+		if !t.config.PrintLogs {
+			continue
+		}
+		initContainers, err := t.kubectl.GetInitContainers(namespace, pod)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		containers, err := t.kubectl.GetContainers(namespace, pod)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		for _, container := range append(initContainers, containers...) {
+			errs = multierror.Append(errs, t.writeArtifact(dir, fmt.Sprintf("logs-%s-%s.txt", pod, container), func(t *Testing) error {
+				return t.kubectl.Logs(namespace, pod, container)
+			}))
+			// Previous logs only exist for restarted containers, so failing to get them is expected
+			t.writeArtifact(dir, fmt.Sprintf("logs-%s-%s-previous.txt", pod, container), func(t *Testing) error { // nolint: errcheck
+				return t.kubectl.PreviousLogs(namespace, pod, container)
+			})
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// writeArtifact writes the output of write, which is called with a copy of t writing to a buffer, to the file name
+// in dir. Nothing is written if write fails.
+func (t *Testing) writeArtifact(dir, name string, write func(t *Testing) error) error {
+	var b bytes.Buffer
+	if err := write(t.withOutput(&b)); err != nil {
+		return fmt.Errorf("failed writing %s: %w", name, err)
+	}
+	return os.WriteFile(filepath.Join(dir, name), b.Bytes(), 0644)
+}
+
 func (t *Testing) PrintEventsPodDetailsAndLogs(namespace string, selector string) {
-	util.PrintDelimiterLineToWriter(os.Stdout, "=")
+	util.PrintDelimiterLineToWriter(t.out, "=")
 
 	t.printDetails(namespace, "Events of namespace", ".", func(_ string) error {
 		return t.kubectl.GetEvents(namespace)
@@ -940,7 +1881,7 @@ func (t *Testing) PrintEventsPodDetailsAndLogs(namespace string, selector string
 		"jsonpath={.items[*].metadata.name}",
 	)
 	if err != nil {
-		fmt.Println("Error printing logs:", err)
+		fmt.Fprintln(t.out, "Error printing logs:", err)
 		return
 	}
 
@@ -951,7 +1892,7 @@ func (t *Testing) PrintEventsPodDetailsAndLogs(namespace string, selector string
 
 		initContainers, err := t.kubectl.GetInitContainers(namespace, pod)
 		if err != nil {
-			fmt.Println("Error printing logs:", err)
+			fmt.Fprintln(t.out, "Error printing logs:", err)
 			return
 		}
 
@@ -963,7 +1904,7 @@ func (t *Testing) PrintEventsPodDetailsAndLogs(namespace string, selector string
 
 			containers, err := t.kubectl.GetContainers(namespace, pod)
 			if err != nil {
-				fmt.Printf("failed printing logs: %v\n", err.Error())
+				fmt.Fprintf(t.out, "failed printing logs: %v\n", err.Error())
 				return
 			}
 
@@ -975,7 +1916,7 @@ func (t *Testing) PrintEventsPodDetailsAndLogs(namespace string, selector string
 		}
 	}
 
-	util.PrintDelimiterLineToWriter(os.Stdout, "=")
+	util.PrintDelimiterLineToWriter(t.out, "=")
 }
 
 func (t *Testing) printDetails(resource string, text string, delimiterChar string, printFunc func(item string) error, items ...string) {
@@ -983,24 +1924,24 @@ func (t *Testing) printDetails(resource string, text string, delimiterChar strin
 		item = strings.Trim(item, "'")
 
 		if !t.config.GithubGroups {
-			util.PrintDelimiterLineToWriter(os.Stdout, delimiterChar)
-			fmt.Printf("==> %s %s\n", text, resource)
-			util.PrintDelimiterLineToWriter(os.Stdout, delimiterChar)
+			util.PrintDelimiterLineToWriter(t.out, delimiterChar)
+			fmt.Fprintf(t.out, "==> %s %s\n", text, resource)
+			util.PrintDelimiterLineToWriter(t.out, delimiterChar)
 		} else {
-			util.GithubGroupsBegin(os.Stdout, fmt.Sprintf("%s %s", text, resource))
+			util.GithubGroupsBegin(t.out, fmt.Sprintf("%s %s", text, resource))
 		}
 
 		if err := printFunc(item); err != nil {
-			fmt.Println("Error printing details:", err)
+			fmt.Fprintln(t.out, "Error printing details:", err)
 			return
 		}
 
 		if !t.config.GithubGroups {
-			util.PrintDelimiterLineToWriter(os.Stdout, delimiterChar)
-			fmt.Printf("<== %s %s\n", text, resource)
-			util.PrintDelimiterLineToWriter(os.Stdout, delimiterChar)
+			util.PrintDelimiterLineToWriter(t.out, delimiterChar)
+			fmt.Fprintf(t.out, "<== %s %s\n", text, resource)
+			util.PrintDelimiterLineToWriter(t.out, delimiterChar)
 		} else {
-			util.GithubGroupsEnd(os.Stdout)
+			util.GithubGroupsEnd(t.out)
 		}
 	}
 }
@@ -15,15 +15,30 @@
 package chart
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/helm/chart-testing/v3/pkg/config"
+	"github.com/helm/chart-testing/v3/pkg/exec"
+	"github.com/helm/chart-testing/v3/pkg/tool"
 	"github.com/helm/chart-testing/v3/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	helmignore "helm.sh/helm/v3/pkg/ignore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 type fakeGit struct{}
@@ -36,6 +51,10 @@ func (g fakeGit) Show(_ string, _ string, _ string) (string, error) {
 	return "", nil
 }
 
+func (g fakeGit) CurrentCommit() (string, error) {
+	return "0123456789abcdef", nil
+}
+
 func (g fakeGit) MergeBase(_ string, _ string) (string, error) {
 	return "HEAD", nil
 }
@@ -75,6 +94,10 @@ func (g fakeGit) BranchExists(_ string) bool {
 	return true
 }
 
+func (g fakeGit) ListTags(_ string) ([]string, error) {
+	return []string{"foo-0.9.0", "foo-1.0.0", "foo-bar-1.0.0", "foo-1.1.0-rc.1", "foo-1.1.0"}, nil
+}
+
 type fakeAccountValidator struct{}
 
 func (v fakeAccountValidator) Validate(_ string, account string) error {
@@ -97,8 +120,24 @@ func (l *fakeLinter) Yamale(yamlFile, schemaFile string) error {
 	return nil
 }
 
+type failingLinter struct{}
+
+func (l failingLinter) YamlLint(yamlFile, _ string) error {
+	return fmt.Errorf("yamllint failed for %s", yamlFile)
+}
+func (l failingLinter) Yamale(yamlFile, _ string) error {
+	return fmt.Errorf("schema validation failed for %s", yamlFile)
+}
+
 type fakeHelm struct {
 	mock.Mock
+	template func(chart string, valuesFile string, kubeVersion string) (string, error)
+	// calls records the charts installed and upgraded, any rollbacks, and the releases deleted
+	calls []string
+	// releases lists the releases per namespace
+	releases map[string][]string
+	// failingReleases lists the releases which fail to be deleted
+	failingReleases []string
 }
 
 func (h *fakeHelm) AddRepo(_, _ string, _ []string) error { return nil }
@@ -108,16 +147,44 @@ func (h *fakeHelm) BuildDependenciesWithArgs(chart string, extraArgs []string) e
 	return nil
 }
 func (h *fakeHelm) LintWithValues(_ string, _ string) error { return nil }
-func (h *fakeHelm) InstallWithValues(_ string, _ string, _ string, _ string) error {
+func (h *fakeHelm) InstallWithValues(chart string, _ string, _ string, _ string) error {
+	h.calls = append(h.calls, "install "+chart)
+	return nil
+}
+func (h *fakeHelm) UpgradeWithValues(chart string, _ string, _ string, _ string) error {
+	h.calls = append(h.calls, "upgrade "+chart)
 	return nil
 }
-func (h *fakeHelm) UpgradeWithValues(_ string, _ string, _ string, _ string) error {
+func (h *fakeHelm) Rollback(_ string, _ string) error {
+	h.calls = append(h.calls, "rollback")
 	return nil
 }
 func (h *fakeHelm) Test(_ string, _ string) error {
 	return nil
 }
-func (h *fakeHelm) DeleteRelease(_ string, _ string) {}
+func (h *fakeHelm) DeleteRelease(_ string, release string) error {
+	h.calls = append(h.calls, "delete "+release)
+	if slices.Contains(h.failingReleases, release) {
+		return fmt.Errorf("failed deleting release %q", release)
+	}
+	return nil
+}
+func (h *fakeHelm) Template(chart string, valuesFile string, kubeVersion string) (string, error) {
+	if h.template == nil {
+		return "", nil
+	}
+	return h.template(chart, valuesFile, kubeVersion)
+}
+func (h *fakeHelm) Pull(chart string, version string, destDir string) error {
+	h.Called(chart, version, destDir)
+	return nil
+}
+func (h *fakeHelm) GetManifest(_ string, release string) (string, error) {
+	return fmt.Sprintf("# Source: %s/templates/configmap.yaml\n", release), nil
+}
+func (h *fakeHelm) ListReleases(namespace string) ([]string, error) {
+	return h.releases[namespace], nil
+}
 
 func (h *fakeHelm) Version() (string, error) {
 	return "v3.0.0", nil
@@ -147,6 +214,8 @@ func newTestingMock(cfg config.Configuration) Testing {
 	fakeMockLinter := new(fakeLinter)
 	return Testing{
 		config:           cfg,
+		out:              os.Stdout,
+		resultsOut:       os.Stdout,
 		directoryLister:  util.DirectoryLister{},
 		git:              fakeGit{},
 		utils:            util.Utils{},
@@ -186,6 +255,17 @@ func TestComputeChangedChartDirectories(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestComputeChangedChartDirectoriesReturnsExcludedCharts(t *testing.T) {
+	cfg := config.Configuration{
+		ExcludedCharts: []string{"excluded"},
+		ChartDirs:      []string{"test_chart_at_multi_level/foo"},
+	}
+	ct := newTestingMock(cfg)
+	_, excluded, err := ct.computeChangedChartDirectories()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test_chart_at_multi_level/foo/excluded"}, excluded)
+}
+
 func TestComputeChangedChartDirectoriesWithHelmignore(t *testing.T) {
 	cfg := config.Configuration{
 		ExcludedCharts: []string{"excluded"},
@@ -227,6 +307,50 @@ func TestComputeChangedChartDirectoriesWithMultiLevelChartWithHelmIgnore(t *test
 	assert.ElementsMatch(t, expected, actual)
 }
 
+func TestAddDependentCharts(t *testing.T) {
+	cfg := config.Configuration{
+		ExcludedCharts: []string{"excluded"},
+		ChartDirs:      []string{"testdata/dependents"},
+	}
+	ct := newTestingMock(cfg)
+
+	actual, err := ct.addDependentCharts([]string{"testdata/dependents/library"})
+	expected := []string{"testdata/dependents/library", "testdata/dependents/app", "testdata/dependents/umbrella"}
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+
+	actual, err = ct.addDependentCharts([]string{"testdata/dependents/umbrella", "testdata/dependents/unrelated"})
+	expected = []string{"testdata/dependents/umbrella", "testdata/dependents/unrelated"}
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestAddDependentChartsWithAbsoluteRepository(t *testing.T) {
+	dir := t.TempDir()
+	writeChart := func(name string, repository string) {
+		chartDir := filepath.Join(dir, name)
+		require.NoError(t, os.Mkdir(chartDir, 0755))
+		chartYaml := "apiVersion: v2\nname: " + name + "\nversion: 1.0.0\n"
+		if repository != "" {
+			chartYaml += "dependencies:\n  - name: library\n    version: 1.0.0\n    repository: " + repository + "\n"
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartYaml), 0644))
+	}
+	writeChart("library", "")
+	writeChart("app", "file://"+filepath.Join(dir, "library"))
+
+	// The chart directory is relative, while the repository of the dependency is absolute
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	chartsDir, err := filepath.Rel(wd, dir)
+	require.NoError(t, err)
+	ct := newTestingMock(config.Configuration{ChartDirs: []string{chartsDir}})
+
+	actual, err := ct.addDependentCharts([]string{filepath.Join(chartsDir, "library")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(chartsDir, "library"), filepath.Join(chartsDir, "app")}, actual)
+}
+
 func TestReadAllChartDirectories(t *testing.T) {
 	actual, err := ct.ReadAllChartDirectories()
 	expected := []string{
@@ -306,6 +430,59 @@ func TestLintChartMaintainerValidation(t *testing.T) {
 	runTests(false)
 }
 
+func TestLintChartKeepGoing(t *testing.T) {
+	var testDataSet = []struct {
+		name           string
+		keepGoing      bool
+		expectedSteps  []string
+		expectedErrors []string
+	}{
+		{
+			"stops at first failure",
+			false,
+			[]string{"chart-schema failed"},
+			[]string{"schema validation failed for testdata/no_maintainers/Chart.yaml"},
+		},
+		{
+			"keep going",
+			true,
+			[]string{"chart-schema failed", "yaml-lint failed", "maintainers failed", "helm-lint passed"},
+			[]string{
+				"schema validation failed for testdata/no_maintainers/Chart.yaml",
+				"yamllint failed for testdata/no_maintainers/Chart.yaml",
+				"yamllint failed for testdata/no_maintainers/values.yaml",
+				"chart doesn't have maintainers",
+			},
+		},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			ct := newTestingMock(config.Configuration{
+				ValidateChartSchema: true,
+				ValidateYaml:        true,
+				ValidateMaintainers: true,
+				KeepGoing:           testData.keepGoing,
+			})
+			ct.linter = failingLinter{}
+			ct.out = &bytes.Buffer{}
+			chart, err := NewChart("testdata/no_maintainers")
+			assert.Nil(t, err)
+
+			result := ct.LintChart(chart)
+
+			var steps []string
+			for _, step := range result.Steps {
+				steps = append(steps, fmt.Sprintf("%s %s", step.Name, step.Status))
+			}
+			assert.Equal(t, testData.expectedSteps, steps)
+			for _, expectedError := range testData.expectedErrors {
+				assert.ErrorContains(t, result.Error, expectedError)
+			}
+		})
+	}
+}
+
 func TestLintChartSchemaValidation(t *testing.T) {
 	type testData struct {
 		name     string
@@ -419,6 +596,81 @@ func TestLintDependencyExtraArgs(t *testing.T) {
 	})
 }
 
+func TestLintChartsInParallel(t *testing.T) {
+	charts := []string{
+		"test_charts/foo",
+		"test_charts/bar",
+		"test_charts/must-pass-upgrade-install",
+		"test_charts/simple-deployment",
+		"test_charts/mutating-sfs-volumeclaim",
+	}
+	cfg := config.Configuration{
+		Charts:               charts,
+		SkipHelmDependencies: true,
+		Parallelism:          3,
+	}
+
+	var buf bytes.Buffer
+	ct := newTestingMock(cfg)
+	ct.out = &buf
+
+	results, err := ct.LintCharts()
+	assert.Nil(t, err)
+	assert.Len(t, results, len(charts))
+	for i, result := range results {
+		assert.Equal(t, charts[i], result.Chart.Path())
+		assert.Nil(t, result.Error)
+		assert.Contains(t, buf.String(), fmt.Sprintf("Linting chart %q\n", result.Chart))
+	}
+}
+
+func TestProcessChartsPerKubeContext(t *testing.T) {
+	charts := []string{"test_charts/foo", "test_charts/bar"}
+	for _, parallelism := range []int{1, 2} {
+		t.Run(fmt.Sprintf("parallelism %d", parallelism), func(t *testing.T) {
+			ct := newTestingMock(config.Configuration{
+				Charts:               charts,
+				SkipHelmDependencies: true,
+				Parallelism:          parallelism,
+				KubeContexts:         []string{"kind-a", "kind-b"},
+			})
+			ct.out = io.Discard
+
+			results, err := ct.processCharts(func(t *Testing, chart *Chart) TestResult {
+				fmt.Fprintf(t.out, "Testing in %s\n", t.kubeContext)
+				return TestResult{Chart: chart}
+			})
+			require.NoError(t, err)
+
+			var grid []string
+			for _, result := range results {
+				grid = append(grid, result.Chart.Path()+"@"+result.KubeContext)
+				assert.Equal(t, fmt.Sprintf("Testing in %s\n", result.KubeContext), result.Output)
+			}
+			assert.Equal(t, []string{
+				"test_charts/foo@kind-a",
+				"test_charts/foo@kind-b",
+				"test_charts/bar@kind-a",
+				"test_charts/bar@kind-b",
+			}, grid)
+		})
+	}
+
+	t.Run("linting ignores kube contexts", func(t *testing.T) {
+		ct := newTestingMock(config.Configuration{
+			Charts:               charts,
+			SkipHelmDependencies: true,
+			KubeContexts:         []string{"kind-a", "kind-b"},
+		})
+		ct.out = io.Discard
+
+		results, err := ct.LintCharts()
+		require.NoError(t, err)
+		assert.Len(t, results, len(charts))
+		assert.Empty(t, results[0].KubeContext)
+	})
+}
+
 func TestGenerateInstallConfig(t *testing.T) {
 	type testData struct {
 		name  string
@@ -467,7 +719,7 @@ func TestGenerateInstallConfig(t *testing.T) {
 		t.Run(testData.name, func(t *testing.T) {
 			ct := newTestingMock(testData.cfg)
 
-			namespace, release, releaseSelector, _ := ct.generateInstallConfig(testData.chart)
+			namespace, release, releaseSelector, _ := ct.generateInstallConfig(testData.chart, "")
 			assert.NotEqual(t, "", namespace)
 			assert.NotEqual(t, "", release)
 			assert.True(t, len(release) < 64, "release should be less than 64 chars")
@@ -569,3 +821,230 @@ func TestChart_AdditionalCommandsAreRun(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintResultsListsSteps(t *testing.T) {
+	ct := newTestingMock(config.Configuration{GithubGroups: true})
+	var b strings.Builder
+	ct.out = &b
+
+	result := TestResult{Chart: &Chart{path: "charts/foo", yaml: &util.ChartYaml{Name: "foo", Version: "1.0.0"}}}
+	assert.NoError(t, result.runStep(StepInstall, "charts/foo/ci/a-values.yaml", func() error {
+		return nil
+	}))
+	assert.Error(t, result.runStep(StepHelmTest, "charts/foo/ci/a-values.yaml", func() error {
+		return errors.New("test failed")
+	}))
+	result.skipStep(StepUpgradePrevious, "")
+	for i := range result.Steps {
+		result.Steps[i].Duration = 1500 * time.Millisecond
+	}
+
+	assert.EqualError(t, result.Error, "test failed")
+	assert.Equal(t, []StepStatus{StatusPassed, StatusFailed, StatusSkipped},
+		[]StepStatus{result.Steps[0].Status, result.Steps[1].Status, result.Steps[2].Status})
+	assert.False(t, result.Steps[0].StartTime.IsZero())
+
+	ct.PrintResults([]TestResult{result})
+
+	expected := "::group::Test Results\n" +
+		" ✖︎ foo => (version: \"1.0.0\", path: \"charts/foo\") > test failed\n" +
+		"     ✔︎ install [a-values.yaml] (1.5s)\n" +
+		"     ✖︎ helm-test [a-values.yaml] (1.5s) > test failed\n" +
+		"     - upgrade-previous (1.5s)\n" +
+		"::endgroup::\n"
+	assert.Equal(t, expected, b.String())
+
+	b.Reset()
+	ct.PrintResults([]TestResult{{Chart: result.Chart, KubeContext: "kind-a"}})
+	assert.Contains(t, b.String(), " ✔︎ foo => (version: \"1.0.0\", path: \"charts/foo\") @ kind-a\n")
+}
+
+func TestWriteEventsPodDetailsAndLogs(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "foo", Labels: map[string]string{"app": "web"}},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init"}},
+			Containers:     []corev1.Container{{Name: "web", Image: "nginx"}},
+		},
+	}
+	client := fake.NewClientset(pod)
+	kubeClient := func() (kubernetes.Interface, error) { return client, nil }
+
+	var b strings.Builder
+	ct := newTestingMock(config.Configuration{
+		ArtifactsDir:     t.TempDir(),
+		KubernetesClient: "client-go",
+		KubectlTimeout:   time.Second,
+		PrintLogs:        true,
+	})
+	ct.out = &b
+	procExec := exec.NewProcessExecutor(false)
+	ct.procExec = &procExec
+	ct.kubeClient = kubeClient
+	ct.kubectl = tool.NewClientGoKubectl(kubeClient, &b, time.Second, time.Second)
+
+	chart := &Chart{yaml: &util.ChartYaml{Name: "web"}}
+	ct.reportDiagnostics(chart, "ci/ha-values.yaml", "foo", "web-abc", "app=web")
+
+	dir := filepath.Join(ct.config.ArtifactsDir, "web", "ha-values", "web-abc")
+	assert.Equal(t, fmt.Sprintf("Diagnostics of release \"web-abc\" written to %s\n", dir), b.String())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var files []string
+	for _, entry := range entries {
+		files = append(files, entry.Name())
+	}
+	assert.Equal(t, []string{
+		"describe-web-1.txt",
+		"events.txt",
+		"logs-web-1-init-previous.txt",
+		"logs-web-1-init.txt",
+		"logs-web-1-web-previous.txt",
+		"logs-web-1-web.txt",
+		"manifest.yaml",
+	}, files)
+
+	describe, err := os.ReadFile(filepath.Join(dir, "describe-web-1.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(describe), "Name:      web-1\n")
+	manifest, err := os.ReadFile(filepath.Join(dir, "manifest.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Source: web-abc/templates/configmap.yaml\n", string(manifest))
+}
+
+func TestRunChart(t *testing.T) {
+	// install waits for the chart to be canceled unless it is bound to pass
+	install := func(pass bool) func(t *Testing, chart *Chart) TestResult {
+		return func(t *Testing, chart *Chart) TestResult {
+			result := TestResult{Chart: chart}
+			result.runStep(StepInstall, "", func() error { // nolint: errcheck
+				if pass {
+					return nil
+				}
+				<-t.context().Done()
+				return fmt.Errorf("failed waiting for process: %w", context.Cause(t.context()))
+			})
+			return result
+		}
+	}
+	expired, cancel := context.WithTimeoutCause(context.Background(), 0, timeoutError{"total timeout", time.Minute})
+	defer cancel()
+	interrupted, interrupt := context.WithCancelCause(context.Background())
+	interrupt(errors.New("interrupted by signal: terminated"))
+
+	var testDataSet = []struct {
+		name          string
+		ctx           context.Context
+		pass          bool
+		expectedError string
+		expectedSteps int
+		timedOut      bool
+	}{
+		{"passed", context.Background(), true, "", 1, false},
+		{"chart timeout exceeded", context.Background(), false, "failed waiting for process: exceeded chart timeout of 10ms", 1, true},
+		{"total timeout exceeded", expired, true, "exceeded total timeout of 1m0s", 0, true},
+		{"interrupted", interrupted, true, "interrupted by signal: terminated", 0, false},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			ct := newTestingMock(config.Configuration{ChartTimeout: 10 * time.Millisecond})
+			ct.out = io.Discard
+			chart := &Chart{path: "foo", yaml: &util.ChartYaml{Name: "foo"}}
+
+			result := ct.runChart(testData.ctx, chart, install(testData.pass))
+			assert.Len(t, result.Steps, testData.expectedSteps)
+			assert.Equal(t, testData.timedOut, result.TimedOut)
+			if testData.expectedError == "" {
+				assert.NoError(t, result.Error)
+				return
+			}
+			assert.EqualError(t, result.Error, testData.expectedError)
+		})
+	}
+}
+
+func TestWithCancellation(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	cleanupCtx, cancelCleanup := context.WithCancel(context.Background())
+	defer cancelCleanup()
+
+	ct := newTestingMock(config.Configuration{})
+	withCancellation := ct.WithCancellation(canceled, cleanupCtx)
+	assert.Error(t, withCancellation.context().Err())
+	// Cleaning up is only canceled along with the cleanup context
+	assert.Equal(t, cleanupCtx, withCancellation.withConfig(ct.config).uncanceled().context())
+
+	// Without cleanup context, cleaning up is never canceled
+	assert.NoError(t, ct.withContext(canceled).uncanceled().context().Err())
+}
+
+func TestRetry(t *testing.T) {
+	var testDataSet = []struct {
+		name          string
+		retries       int
+		failures      int
+		expectedCalls int
+		expectedFlaky bool
+		expectError   bool
+	}{
+		{"passed", 2, 0, 1, false, false},
+		{"passed after retry", 2, 2, 3, true, false},
+		{"failed without retries", 0, 1, 1, false, true},
+		{"failed after retries", 2, 3, 3, false, true},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			ct := newTestingMock(config.Configuration{Retries: testData.retries, RetryBackoff: time.Millisecond})
+			ct.out = io.Discard
+
+			var calls int
+			var flaky bool
+			err := ct.retry(&flaky, func() error {
+				calls++
+				if calls <= testData.failures {
+					return errors.New("failed testing release")
+				}
+				return nil
+			})
+			assert.Equal(t, testData.expectError, err != nil)
+			assert.Equal(t, testData.expectedCalls, calls)
+			assert.Equal(t, testData.expectedFlaky, flaky)
+		})
+	}
+}
+
+func TestAllFailuresQuarantined(t *testing.T) {
+	chart := &Chart{path: "charts/foo", yaml: &util.ChartYaml{Name: "foo"}}
+	failed := func(valuesFiles ...string) TestResult {
+		result := TestResult{Chart: chart, Error: errors.New("failed")}
+		for _, valuesFile := range valuesFiles {
+			result.Steps = append(result.Steps, StepResult{Name: StepHelmTest, ValuesFile: valuesFile, Status: StatusFailed})
+		}
+		return result
+	}
+
+	var testDataSet = []struct {
+		name       string
+		quarantine []string
+		result     TestResult
+		expected   bool
+	}{
+		{"not quarantined", []string{"bar"}, failed("charts/foo/ci/a-values.yaml"), false},
+		{"chart by name", []string{"foo"}, failed("charts/foo/ci/a-values.yaml"), true},
+		{"chart by path", []string{"./charts/foo/"}, failed(""), true},
+		{"chart failed before steps", []string{"charts/foo"}, failed(), true},
+		{"values file", []string{"charts/foo/ci/a-values.yaml"}, failed("charts/foo/ci/a-values.yaml"), true},
+		{"other values file failed too", []string{"charts/foo/ci/a-values.yaml"}, failed("charts/foo/ci/a-values.yaml", "charts/foo/ci/b-values.yaml"), false},
+		{"values file only", []string{"charts/foo/ci/a-values.yaml"}, failed(), false},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			ct := newTestingMock(config.Configuration{Quarantine: testData.quarantine})
+			assert.Equal(t, testData.expected, ct.allFailuresQuarantined(testData.result))
+		})
+	}
+}
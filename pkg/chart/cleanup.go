@@ -0,0 +1,167 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// managedByLabel marks the namespaces created by ct, so that leaked ones can be found by 'ct cleanup'.
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "chart-testing"
+
+	annotationPrefix         = "chart-testing.helm.sh/"
+	buildIDAnnotation        = annotationPrefix + "build-id"
+	chartAnnotation          = annotationPrefix + "chart"
+	commitAnnotation         = annotationPrefix + "commit"
+	createdAtAnnotation      = annotationPrefix + "created-at"
+	managedNamespaceSelector = managedByLabel + "=" + managedByValue
+)
+
+// namespaceMetadata returns the labels and annotations of a namespace created for installing chart.
+func (t *Testing) namespaceMetadata(chart *Chart) (labels map[string]string, annotations map[string]string) {
+	labels = map[string]string{managedByLabel: managedByValue}
+	annotations = map[string]string{
+		chartAnnotation:     fmt.Sprintf("%s-%s", chart.yaml.Name, chart.yaml.Version),
+		createdAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+	}
+	if t.config.BuildID != "" {
+		annotations[buildIDAnnotation] = t.config.BuildID
+	}
+	// The commit is informational only, so namespaces are created even if it cannot be determined
+	if commit, err := t.git.CurrentCommit(); err == nil && commit != "" {
+		annotations[commitAnnotation] = commit
+	}
+	return labels, annotations
+}
+
+// createNamespace creates a namespace labeled and annotated for installing chart and applies the configured namespace
+// profile to it, if any.
+func (t *Testing) createNamespace(chart *Chart, namespace string) error {
+	labels, annotations := t.namespaceMetadata(chart)
+	var objects []runtime.Object
+	if name := t.config.NamespaceProfile; name != "" {
+		profileLabels, profileObjects, err := namespaceProfile(t.config.NamespaceProfiles[name])
+		if err != nil {
+			return fmt.Errorf("invalid namespace profile %q: %w", name, err)
+		}
+		maps.Copy(labels, profileLabels)
+		annotations[profileAnnotation] = name
+		objects = profileObjects
+	}
+
+	if err := t.kubectl.CreateNamespace(namespace, labels, annotations); err != nil {
+		return err
+	}
+	if len(objects) > 0 {
+		fmt.Fprintf(t.out, "Applying namespace profile %q to namespace %q...\n", t.config.NamespaceProfile, namespace)
+		if err := t.kubectl.CreateObjects(namespace, objects); err != nil {
+			return fmt.Errorf("failed applying namespace profile %q: %w", t.config.NamespaceProfile, err)
+		}
+	}
+	return nil
+}
+
+// CleanupNamespaces deletes the namespaces created by ct which are older than the configured age and, if configured,
+// belong to the configured build, along with the Helm releases in them. Namespaces are deleted in every configured
+// kube context. The returned error lists the namespaces and releases which could not be deleted.
+func (t *Testing) CleanupNamespaces() error {
+	var errs *multierror.Error
+	for _, t := range t.forKubeContexts() {
+		if err := t.cleanupNamespaces(); err != nil {
+			if t.context().Err() != nil {
+				return err
+			}
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+func (t *Testing) cleanupNamespaces() error {
+	if t.kubeContext != "" {
+		fmt.Fprintf(t.out, "Looking for leaked namespaces in kube context %q...\n", t.kubeContext)
+	} else {
+		fmt.Fprintln(t.out, "Looking for leaked namespaces...")
+	}
+	namespaces, err := t.kubectl.GetNamespaces(managedNamespaceSelector)
+	if err != nil {
+		return fmt.Errorf("failed listing namespaces: %w", err)
+	}
+
+	now := time.Now()
+	var deleted int
+	var errs *multierror.Error
+	for _, namespace := range namespaces {
+		if !t.isLeaked(namespace, now) {
+			continue
+		}
+		// No further namespaces are cleaned up once interrupted, but the current one is finished
+		if err := t.context().Err(); err != nil {
+			return context.Cause(t.context())
+		}
+		t := t.uncanceled()
+
+		fmt.Fprintf(t.out, "Cleaning up namespace %q of chart %q created at %s...\n", namespace.Name,
+			namespace.Annotations[chartAnnotation], createdAt(namespace).Format(time.RFC3339))
+		releases, err := t.helm.ListReleases(namespace.Name)
+		if err != nil {
+			// Deleting the namespace deletes the releases' resources in it anyway
+			fmt.Fprintln(t.out, "Error listing Helm releases:", err)
+		}
+		var failed bool
+		for _, release := range releases {
+			if err := t.helm.DeleteRelease(namespace.Name, release); err != nil {
+				fmt.Fprintln(t.out, "Error deleting Helm release:", err)
+				errs = multierror.Append(errs, fmt.Errorf("namespace %q: %w", namespace.Name, err))
+				failed = true
+			}
+		}
+		if err := t.kubectl.DeleteNamespace(namespace.Name); err != nil {
+			fmt.Fprintln(t.out, "Error deleting namespace:", err)
+			errs = multierror.Append(errs, err)
+			failed = true
+		}
+		if !failed {
+			deleted++
+		}
+	}
+	fmt.Fprintf(t.out, "Cleaned up %d of %d namespaces created by ct.\n", deleted, len(namespaces))
+	return errs.ErrorOrNil()
+}
+
+// isLeaked returns whether namespace is older than the configured age and belongs to the configured build, if any.
+func (t *Testing) isLeaked(namespace corev1.Namespace, now time.Time) bool {
+	if t.config.BuildID != "" && namespace.Annotations[buildIDAnnotation] != t.config.BuildID {
+		return false
+	}
+	return now.Sub(createdAt(namespace)) >= t.config.OlderThan
+}
+
+// createdAt returns the creation time recorded by ct, falling back to the creation time of the namespace object.
+func createdAt(namespace corev1.Namespace) time.Time {
+	if createdAt, err := time.Parse(time.RFC3339, namespace.Annotations[createdAtAnnotation]); err == nil {
+		return createdAt
+	}
+	return namespace.CreationTimestamp.Time
+}
@@ -0,0 +1,155 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/helm/chart-testing/v3/pkg/config"
+	"github.com/helm/chart-testing/v3/pkg/tool"
+	"github.com/helm/chart-testing/v3/pkg/util"
+)
+
+func TestCreateNamespace(t *testing.T) {
+	client := fake.NewClientset()
+	kubeClient := func() (kubernetes.Interface, error) { return client, nil }
+	ct := newTestingMock(config.Configuration{BuildID: "pr-42"})
+	ct.kubectl = tool.NewClientGoKubectl(kubeClient, io.Discard, time.Second, time.Second)
+
+	chart := &Chart{path: "charts/foo", yaml: &util.ChartYaml{Name: "foo", Version: "1.2.0"}}
+	require.NoError(t, ct.createNamespace(chart, "foo-pr-42-abcdef"))
+
+	namespace, err := client.CoreV1().Namespaces().Get(context.Background(), "foo-pr-42-abcdef", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app.kubernetes.io/managed-by": "chart-testing"}, namespace.Labels)
+	assert.Equal(t, "pr-42", namespace.Annotations["chart-testing.helm.sh/build-id"])
+	assert.Equal(t, "foo-1.2.0", namespace.Annotations["chart-testing.helm.sh/chart"])
+	assert.Equal(t, "0123456789abcdef", namespace.Annotations["chart-testing.helm.sh/commit"])
+	createdAt, err := time.Parse(time.RFC3339, namespace.Annotations["chart-testing.helm.sh/created-at"])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), createdAt, time.Minute)
+}
+
+func TestCleanupNamespaces(t *testing.T) {
+	now := time.Now()
+	namespace := func(name string, managed bool, buildID string, age time.Duration) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if managed {
+			ns.Labels = map[string]string{managedByLabel: managedByValue}
+		}
+		if buildID != "" {
+			ns.Annotations = map[string]string{
+				buildIDAnnotation:   buildID,
+				createdAtAnnotation: now.Add(-age).Format(time.RFC3339),
+			}
+		} else {
+			// Without annotation, the creation time of the namespace object is used
+			ns.CreationTimestamp = metav1.NewTime(now.Add(-age))
+		}
+		return ns
+	}
+
+	var testDataSet = []struct {
+		name              string
+		buildID           string
+		failingReleases   []string
+		expectedRemaining []string
+		expectedCalls     []string
+		expectedError     string
+		expectedOutput    string
+	}{
+		{
+			"all builds",
+			"",
+			nil,
+			[]string{"foo-pr-42-recent", "unmanaged"},
+			[]string{"delete bar", "delete foo"},
+			"",
+			"Cleaned up 3 of 4 namespaces created by ct.",
+		},
+		{
+			"specific build",
+			"pr-42",
+			nil,
+			[]string{"bar-pr-7-old", "foo-pr-42-recent", "no-build-old", "unmanaged"},
+			[]string{"delete foo"},
+			"",
+			"Cleaned up 1 of 4 namespaces created by ct.",
+		},
+		{
+			"failing release deletion",
+			"",
+			[]string{"bar"},
+			[]string{"foo-pr-42-recent", "unmanaged"},
+			[]string{"delete bar", "delete foo"},
+			`namespace "bar-pr-7-old": failed deleting release "bar"`,
+			"Cleaned up 2 of 4 namespaces created by ct.",
+		},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			client := fake.NewClientset(
+				namespace("foo-pr-42-old", true, "pr-42", 3*time.Hour),
+				namespace("foo-pr-42-recent", true, "pr-42", time.Hour),
+				namespace("bar-pr-7-old", true, "pr-7", 3*time.Hour),
+				namespace("no-build-old", true, "", 3*time.Hour),
+				namespace("unmanaged", false, "", 3*time.Hour),
+			)
+			kubeClient := func() (kubernetes.Interface, error) { return client, nil }
+			ct := newTestingMock(config.Configuration{OlderThan: 2 * time.Hour, BuildID: testData.buildID})
+			var out strings.Builder
+			ct.out = &out
+			helm := &fakeHelm{
+				releases: map[string][]string{
+					"foo-pr-42-old":    {"foo"},
+					"foo-pr-42-recent": {"foo-new"},
+					"bar-pr-7-old":     {"bar"},
+				},
+				failingReleases: testData.failingReleases,
+			}
+			ct.helm = helm
+			ct.kubectl = tool.NewClientGoKubectl(kubeClient, io.Discard, time.Second, time.Second)
+
+			err := ct.CleanupNamespaces()
+			if testData.expectedError != "" {
+				require.ErrorContains(t, err, testData.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Contains(t, out.String(), testData.expectedOutput)
+
+			list, err := client.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+			require.NoError(t, err)
+			var remaining []string
+			for _, ns := range list.Items {
+				remaining = append(remaining, ns.Name)
+			}
+			assert.Equal(t, testData.expectedRemaining, remaining)
+			assert.ElementsMatch(t, testData.expectedCalls, helm.calls)
+		})
+	}
+}
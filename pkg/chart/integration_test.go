@@ -19,6 +19,7 @@ package chart
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -38,13 +39,15 @@ func newTestingHelmIntegration(cfg config.Configuration, extraSetArgs string) Te
 
 	return Testing{
 		config:           cfg,
+		out:              os.Stdout,
+		resultsOut:       os.Stdout,
 		directoryLister:  util.DirectoryLister{},
 		git:              fakeGit{},
 		utils:            util.Utils{},
 		accountValidator: fakeAccountValidator{},
 		linter:           fakeMockLinter,
 		helm:             tool.NewHelm(procExec, extraArgs, extraLintArgs, strings.Fields(extraSetArgs)),
-		kubectl:          tool.NewKubectl(procExec, 30*time.Second),
+		kubectl:          tool.NewKubectl(procExec, 30*time.Second, 5*time.Minute),
 	}
 }
 
@@ -66,7 +69,7 @@ func TestInstallChart(t *testing.T) {
 				ReleaseLabel: "app.kubernetes.io/instance",
 			},
 			"test_charts/must-pass-upgrade-install",
-			TestResult{mustNewChart("test_charts/must-pass-upgrade-install"), nil},
+			TestResult{Chart: mustNewChart("test_charts/must-pass-upgrade-install")},
 			"",
 		},
 		{
@@ -75,7 +78,7 @@ func TestInstallChart(t *testing.T) {
 				Debug: true,
 			},
 			"test_charts/must-pass-upgrade-install",
-			TestResult{mustNewChart("test_charts/must-pass-upgrade-install"), nil},
+			TestResult{Chart: mustNewChart("test_charts/must-pass-upgrade-install")},
 			"",
 		},
 		{
@@ -84,7 +87,7 @@ func TestInstallChart(t *testing.T) {
 				Debug: true,
 			},
 			"test_charts/must-pass-upgrade-install",
-			TestResult{mustNewChart("test_charts/must-pass-upgrade-install"), nil},
+			TestResult{Chart: mustNewChart("test_charts/must-pass-upgrade-install")},
 			"--set=image.tag=latest",
 		},
 	}
@@ -94,8 +97,8 @@ func TestInstallChart(t *testing.T) {
 			ct := newTestingHelmIntegration(tc.cfg, tc.extraSet)
 			namespace := tc.cfg.Namespace
 			if namespace != "" {
-				ct.kubectl.CreateNamespace(namespace)
-				defer ct.kubectl.DeleteNamespace(namespace)
+				ct.kubectl.CreateNamespace(namespace, nil, nil)
+				defer ct.kubectl.DeleteNamespace(namespace) // nolint: errcheck
 			}
 			result := ct.InstallChart(mustNewChart(tc.chartDir))
 
@@ -154,7 +157,8 @@ func TestUpgradeChart(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := ct.doUpgrade(mustNewChart(tc.old), mustNewChart(tc.new), true)
+			result := TestResult{Chart: mustNewChart(tc.new)}
+			err := ct.doUpgrade(&result, mustNewChart(tc.old), mustNewChart(tc.new), true)
 
 			if err != tc.err {
 				if err != nil && tc.err != nil {
@@ -0,0 +1,139 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes the results to the file configured with '--junit-report' as JUnit XML. The results are
+// written as one testsuite named suiteName with one testcase per step, that is per chart, check, and CI values file.
+// Nothing is written if no report file is configured.
+func (t *Testing) WriteJUnitReport(suiteName string, results []TestResult) error {
+	if t.config.JUnitReport == "" {
+		return nil
+	}
+
+	file, err := os.Create(t.config.JUnitReport)
+	if err != nil {
+		return fmt.Errorf("failed creating JUnit report: %w", err)
+	}
+	defer file.Close() // nolint: errcheck
+
+	return writeJUnitReport(file, suiteName, results)
+}
+
+func writeJUnitReport(w io.Writer, suiteName string, results []TestResult) error {
+	suite := junitTestSuite{Name: suiteName}
+	var firstStart time.Time
+	for _, result := range results {
+		for _, step := range resultSteps(result, suiteName) {
+			if !step.StartTime.IsZero() && (firstStart.IsZero() || step.StartTime.Before(firstStart)) {
+				firstStart = step.StartTime
+			}
+
+			testCase := junitTestCase{
+				Name:      step.displayName(),
+				ClassName: result.Chart.Path(),
+				Time:      fmt.Sprintf("%.3f", step.Duration.Seconds()),
+			}
+			// Results of different kube contexts are told apart by their class name
+			if result.KubeContext != "" {
+				testCase.ClassName = fmt.Sprintf("%s@%s", result.Chart.Path(), result.KubeContext)
+			}
+			switch step.Status {
+			case StatusSkipped:
+				message := "skipped"
+				if result.Excluded {
+					message = "excluded"
+				} else if result.Skipped {
+					message = "deprecated"
+				}
+				testCase.Skipped = &junitSkipped{Message: message}
+				suite.Skipped++
+			case StatusFailed:
+				message := "failed"
+				if step.Error != nil {
+					message = step.Error.Error()
+				}
+				// Quarantined failures do not fail the run and therefore not the report either
+				if result.Quarantined {
+					testCase.Skipped = &junitSkipped{Message: "quarantined: " + message}
+					testCase.SystemOut = result.Output
+					suite.Skipped++
+					break
+				}
+				testCase.Failure = &junitFailure{
+					Message:  message,
+					Contents: message,
+				}
+				testCase.SystemOut = result.Output
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+	}
+	if !firstStart.IsZero() {
+		suite.Timestamp = firstStart.UTC().Format("2006-01-02T15:04:05")
+	}
+	suite.Tests = len(suite.TestCases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return fmt.Errorf("failed encoding JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
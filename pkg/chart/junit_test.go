@@ -0,0 +1,96 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJUnitReport(t *testing.T) {
+	results := []TestResult{
+		{
+			Chart: &Chart{path: "charts/foo"},
+			Steps: []StepResult{
+				{
+					Name:      StepChartSchema,
+					StartTime: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+					Duration:  1500 * time.Millisecond,
+					Status:    StatusPassed,
+				},
+				{Name: StepHelmLint, ValuesFile: "charts/foo/ci/a-values.yaml", Status: StatusPassed},
+				{Name: StepHelmLint, ValuesFile: "charts/foo/ci/b-values.yaml", Status: StatusSkipped},
+			},
+		},
+		{
+			Chart:  &Chart{path: "charts/bar"},
+			Error:  errors.New("chart doesn't have maintainers"),
+			Output: "Validating maintainers...\n",
+			Steps: []StepResult{
+				{Name: StepMaintainers, Status: StatusFailed, Error: errors.New("chart doesn't have maintainers")},
+			},
+		},
+		{
+			Chart:       &Chart{path: "charts/qux"},
+			Error:       errors.New("failed testing release"),
+			Output:      "Running tests...\n",
+			Quarantined: true,
+			Steps: []StepResult{
+				{Name: StepHelmTest, ValuesFile: "charts/qux/ci/a-values.yaml", Status: StatusFailed, Error: errors.New("failed testing release")},
+			},
+		},
+		{
+			Chart: &Chart{path: "charts/baz"},
+		},
+		{
+			Chart:    &Chart{path: "charts/common"},
+			Skipped:  true,
+			Excluded: true,
+		},
+	}
+
+	var b strings.Builder
+	err := writeJUnitReport(&b, "lint", results)
+	assert.Nil(t, err)
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="lint" timestamp="2024-05-01T12:00:00" tests="7" failures="1" skipped="3">
+    <testcase name="chart-schema" classname="charts/foo" time="1.500"></testcase>
+    <testcase name="helm-lint [a-values.yaml]" classname="charts/foo" time="0.000"></testcase>
+    <testcase name="helm-lint [b-values.yaml]" classname="charts/foo" time="0.000">
+      <skipped message="skipped"></skipped>
+    </testcase>
+    <testcase name="maintainers" classname="charts/bar" time="0.000">
+      <failure message="chart doesn&#39;t have maintainers">chart doesn&#39;t have maintainers</failure>
+      <system-out>Validating maintainers...&#xA;</system-out>
+    </testcase>
+    <testcase name="helm-test [a-values.yaml]" classname="charts/qux" time="0.000">
+      <skipped message="quarantined: failed testing release"></skipped>
+      <system-out>Running tests...&#xA;</system-out>
+    </testcase>
+    <testcase name="lint" classname="charts/baz" time="0.000"></testcase>
+    <testcase name="lint" classname="charts/common" time="0.000">
+      <skipped message="excluded"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	assert.Equal(t, expected, b.String())
+}
@@ -0,0 +1,269 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	// latestKubeMinor is the latest minor release of Kubernetes 1.x known to the API table.
+	latestKubeMinor = 35
+	// maxKubePatch is the highest patch release considered when matching kubeVersion constraints.
+	maxKubePatch = 50
+)
+
+// kubeAPI describes in which minor releases of Kubernetes 1.x an API version of a kind has been introduced,
+// deprecated, and removed. Zero means the API exists since before 1.0 or has not been deprecated or removed.
+type kubeAPI struct {
+	apiVersion  string
+	kind        string
+	introduced  int
+	deprecated  int
+	removed     int
+	replacement string
+}
+
+// kubeAPIs lists the API versions of built-in kinds which have been added after 1.0 or removed.
+// See https://kubernetes.io/docs/reference/using-api/deprecation-guide/
+var kubeAPIs = []kubeAPI{
+	{"extensions/v1beta1", "Deployment", 0, 9, 16, "apps/v1"},
+	{"extensions/v1beta1", "DaemonSet", 0, 9, 16, "apps/v1"},
+	{"extensions/v1beta1", "ReplicaSet", 0, 9, 16, "apps/v1"},
+	{"extensions/v1beta1", "NetworkPolicy", 0, 9, 16, "networking.k8s.io/v1"},
+	{"extensions/v1beta1", "PodSecurityPolicy", 0, 11, 16, "policy/v1beta1"},
+	{"extensions/v1beta1", "Ingress", 0, 14, 22, "networking.k8s.io/v1"},
+	{"apps/v1beta1", "Deployment", 0, 9, 16, "apps/v1"},
+	{"apps/v1beta1", "StatefulSet", 0, 9, 16, "apps/v1"},
+	{"apps/v1beta2", "Deployment", 8, 9, 16, "apps/v1"},
+	{"apps/v1beta2", "StatefulSet", 8, 9, 16, "apps/v1"},
+	{"apps/v1beta2", "DaemonSet", 8, 9, 16, "apps/v1"},
+	{"apps/v1beta2", "ReplicaSet", 8, 9, 16, "apps/v1"},
+	{"apps/v1", "Deployment", 9, 0, 0, ""},
+	{"apps/v1", "StatefulSet", 9, 0, 0, ""},
+	{"apps/v1", "DaemonSet", 9, 0, 0, ""},
+	{"apps/v1", "ReplicaSet", 9, 0, 0, ""},
+	{"networking.k8s.io/v1beta1", "Ingress", 14, 19, 22, "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "IngressClass", 18, 19, 22, "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1", "Ingress", 19, 0, 0, ""},
+	{"networking.k8s.io/v1", "IngressClass", 19, 0, 0, ""},
+	{"admissionregistration.k8s.io/v1beta1", "MutatingWebhookConfiguration", 9, 16, 22, "admissionregistration.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", 9, 16, 22, "admissionregistration.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1", "MutatingWebhookConfiguration", 16, 0, 0, ""},
+	{"admissionregistration.k8s.io/v1", "ValidatingWebhookConfiguration", 16, 0, 0, ""},
+	{"admissionregistration.k8s.io/v1", "ValidatingAdmissionPolicy", 30, 0, 0, ""},
+	{"admissionregistration.k8s.io/v1", "ValidatingAdmissionPolicyBinding", 30, 0, 0, ""},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", 7, 16, 22, "apiextensions.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1", "CustomResourceDefinition", 16, 0, 0, ""},
+	{"apiregistration.k8s.io/v1beta1", "APIService", 7, 19, 22, "apiregistration.k8s.io/v1"},
+	{"apiregistration.k8s.io/v1", "APIService", 10, 0, 0, ""},
+	{"certificates.k8s.io/v1beta1", "CertificateSigningRequest", 0, 19, 22, "certificates.k8s.io/v1"},
+	{"certificates.k8s.io/v1", "CertificateSigningRequest", 19, 0, 0, ""},
+	{"coordination.k8s.io/v1beta1", "Lease", 12, 19, 22, "coordination.k8s.io/v1"},
+	{"coordination.k8s.io/v1", "Lease", 14, 0, 0, ""},
+	{"rbac.authorization.k8s.io/v1alpha1", "ClusterRole", 0, 17, 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1alpha1", "ClusterRoleBinding", 0, 17, 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1alpha1", "Role", 0, 17, 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1alpha1", "RoleBinding", 0, 17, 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRole", 6, 17, 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRoleBinding", 6, 17, 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "Role", 6, 17, 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "RoleBinding", 6, 17, 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1", "ClusterRole", 8, 0, 0, ""},
+	{"rbac.authorization.k8s.io/v1", "ClusterRoleBinding", 8, 0, 0, ""},
+	{"rbac.authorization.k8s.io/v1", "Role", 8, 0, 0, ""},
+	{"rbac.authorization.k8s.io/v1", "RoleBinding", 8, 0, 0, ""},
+	{"scheduling.k8s.io/v1alpha1", "PriorityClass", 8, 14, 17, "scheduling.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass", 11, 14, 22, "scheduling.k8s.io/v1"},
+	{"scheduling.k8s.io/v1", "PriorityClass", 14, 0, 0, ""},
+	{"storage.k8s.io/v1beta1", "CSIDriver", 14, 19, 22, "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSINode", 14, 17, 22, "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "StorageClass", 0, 19, 22, "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "VolumeAttachment", 10, 19, 22, "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSIStorageCapacity", 21, 24, 27, "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1", "CSIDriver", 18, 0, 0, ""},
+	{"storage.k8s.io/v1", "CSINode", 17, 0, 0, ""},
+	{"storage.k8s.io/v1", "VolumeAttachment", 13, 0, 0, ""},
+	{"storage.k8s.io/v1", "CSIStorageCapacity", 24, 0, 0, ""},
+	{"batch/v1beta1", "CronJob", 8, 21, 25, "batch/v1"},
+	{"batch/v1", "CronJob", 21, 0, 0, ""},
+	{"discovery.k8s.io/v1beta1", "EndpointSlice", 17, 21, 25, "discovery.k8s.io/v1"},
+	{"discovery.k8s.io/v1", "EndpointSlice", 21, 0, 0, ""},
+	{"events.k8s.io/v1beta1", "Event", 8, 19, 25, "events.k8s.io/v1"},
+	{"events.k8s.io/v1", "Event", 19, 0, 0, ""},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", 8, 22, 25, "autoscaling/v2"},
+	{"autoscaling/v2beta2", "HorizontalPodAutoscaler", 12, 23, 26, "autoscaling/v2"},
+	{"autoscaling/v2", "HorizontalPodAutoscaler", 23, 0, 0, ""},
+	{"policy/v1beta1", "PodDisruptionBudget", 5, 21, 25, "policy/v1"},
+	{"policy/v1beta1", "PodSecurityPolicy", 10, 21, 25, ""},
+	{"policy/v1", "PodDisruptionBudget", 21, 0, 0, ""},
+	{"node.k8s.io/v1beta1", "RuntimeClass", 14, 20, 25, "node.k8s.io/v1"},
+	{"node.k8s.io/v1", "RuntimeClass", 20, 0, 0, ""},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "FlowSchema", 20, 23, 26, "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "PriorityLevelConfiguration", 20, 23, 26, "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "FlowSchema", 23, 26, 29, "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "PriorityLevelConfiguration", 23, 26, 29, "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "FlowSchema", 26, 29, 32, "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "PriorityLevelConfiguration", 26, 29, 32, "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1", "FlowSchema", 29, 0, 0, ""},
+	{"flowcontrol.apiserver.k8s.io/v1", "PriorityLevelConfiguration", 29, 0, 0, ""},
+}
+
+// lookupKubeAPI returns the entry of the API table for apiVersion and kind, if any.
+func lookupKubeAPI(apiVersion, kind string) (kubeAPI, bool) {
+	i := slices.IndexFunc(kubeAPIs, func(api kubeAPI) bool {
+		return api.apiVersion == apiVersion && api.kind == kind
+	})
+	if i < 0 {
+		return kubeAPI{}, false
+	}
+	return kubeAPIs[i], true
+}
+
+// availableIn returns whether the API is served by Kubernetes 1.minor.
+func (a kubeAPI) availableIn(minor int) bool {
+	return minor >= a.introduced && (a.removed == 0 || minor < a.removed)
+}
+
+func (a kubeAPI) unavailableReason(minor int) string {
+	if minor < a.introduced {
+		return fmt.Sprintf("introduced in 1.%d", a.introduced)
+	}
+	reason := fmt.Sprintf("removed in 1.%d", a.removed)
+	if a.replacement != "" {
+		reason += fmt.Sprintf(", use %s", a.replacement)
+	}
+	return reason
+}
+
+// kubeVersionsToCheck returns the Kubernetes versions the chart is checked against: the versions configured with
+// '--kube-versions' which satisfy the chart's 'kubeVersion' constraint, and the versions within that constraint at
+// which an API of the table is introduced, deprecated, or removed as well as its lowest and highest version.
+func (t *Testing) kubeVersionsToCheck(chart *Chart) ([]*semver.Version, error) {
+	var constraint *semver.Constraints
+	if kubeVersion := chart.kubeVersion(); kubeVersion != "" {
+		var err error
+		constraint, err = semver.NewConstraint(kubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubeVersion %q in Chart.yaml: %w", kubeVersion, err)
+		}
+	}
+
+	var versions []*semver.Version
+	for _, v := range t.config.KubeVersions {
+		version, err := semver.NewVersion(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Kubernetes version %q: %w", v, err)
+		}
+		if slices.ContainsFunc(versions, version.Equal) {
+			continue
+		}
+		if constraint != nil && !constraint.Check(version) {
+			fmt.Fprintf(t.out, "Kubernetes %s skipped because the chart requires kubeVersion %q\n", v, chart.kubeVersion())
+			continue
+		}
+		versions = append(versions, version)
+	}
+	if constraint == nil {
+		return versions, nil
+	}
+
+	boundaries := map[int]bool{}
+	for _, api := range kubeAPIs {
+		boundaries[api.introduced] = true
+		boundaries[api.deprecated] = true
+		boundaries[api.removed] = true
+	}
+	// The first matching patch release of every minor release within the constraint
+	var admitted []*semver.Version
+	for minor := 0; minor <= latestKubeMinor; minor++ {
+		for patch := 0; patch <= maxKubePatch; patch++ {
+			version := semver.MustParse(fmt.Sprintf("1.%d.%d", minor, patch))
+			if constraint.Check(version) {
+				admitted = append(admitted, version)
+				break
+			}
+		}
+	}
+	for i, version := range admitted {
+		if slices.ContainsFunc(versions, func(v *semver.Version) bool { return v.Minor() == version.Minor() }) {
+			continue
+		}
+		if i == 0 || i == len(admitted)-1 || boundaries[int(version.Minor())] {
+			versions = append(versions, version)
+		}
+	}
+	slices.SortFunc(versions, (*semver.Version).Compare)
+	return versions, nil
+}
+
+// CheckKubeAPIs renders the chart with valuesFile for every Kubernetes version the chart is checked against and fails
+// if an object uses an API version which is not available in that Kubernetes version. Objects using deprecated API
+// versions are reported without failing.
+func (t *Testing) CheckKubeAPIs(chart *Chart, valuesFile string) error {
+	versions, err := t.kubeVersionsToCheck(chart)
+	if err != nil {
+		return err
+	}
+
+	// Versions in which an object is not available, grouped by object and reason
+	var problems []string
+	problemVersions := map[string][]string{}
+	warned := map[string]bool{}
+	for _, version := range versions {
+		fmt.Fprintf(t.out, "Checking API versions for Kubernetes %s...\n", version.Original())
+		manifests, err := t.helm.Template(chart.Path(), valuesFile, version.Original())
+		if err != nil {
+			return fmt.Errorf("failed rendering chart for Kubernetes %s: %w", version.Original(), err)
+		}
+		objects, err := parseManifests(manifests)
+		if err != nil {
+			return err
+		}
+
+		minor := int(version.Minor())
+		for _, object := range objects {
+			api, ok := lookupKubeAPI(object.APIVersion, object.Kind)
+			if !ok {
+				continue
+			}
+			if !api.availableIn(minor) {
+				problem := fmt.Sprintf("%s (%s) is not available in Kubernetes %%s: %s", object, object.Source, api.unavailableReason(minor))
+				if _, ok := problemVersions[problem]; !ok {
+					problems = append(problems, problem)
+				}
+				problemVersions[problem] = append(problemVersions[problem], version.Original())
+			} else if api.deprecated != 0 && minor >= api.deprecated && !warned[object.String()] {
+				warned[object.String()] = true
+				fmt.Fprintf(t.out, "Warning: %s (%s) uses an API version deprecated since Kubernetes 1.%d\n",
+					object, object.Source, api.deprecated)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	var messages []string
+	for _, problem := range problems {
+		messages = append(messages, fmt.Sprintf(problem, strings.Join(problemVersions[problem], ", ")))
+	}
+	return errors.New(strings.Join(messages, "\n"))
+}
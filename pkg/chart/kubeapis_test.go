@@ -0,0 +1,153 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helm/chart-testing/v3/pkg/config"
+	"github.com/helm/chart-testing/v3/pkg/util"
+)
+
+func TestKubeVersionsToCheck(t *testing.T) {
+	var testDataSet = []struct {
+		name         string
+		kubeVersion  string
+		kubeVersions []string
+		expected     []string
+	}{
+		{
+			"no constraint",
+			"",
+			[]string{"1.25", "v1.29.3"},
+			[]string{"1.25", "v1.29.3"},
+		},
+		{
+			"bounded constraint",
+			">=1.19.0-0 <1.23.0",
+			nil,
+			[]string{"1.19.0", "1.20.0", "1.21.0", "1.22.0"},
+		},
+		{
+			"constraint on patch releases",
+			">=1.24.3 <1.26.0",
+			nil,
+			[]string{"1.24.3", "1.25.0"},
+		},
+		{
+			"kube versions outside of the constraint",
+			">=1.30.0-0",
+			[]string{"1.25", "1.31"},
+			[]string{"1.30.0", "1.31", "1.32.0", "1.35.0"},
+		},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			ct := newTestingMock(config.Configuration{KubeVersions: testData.kubeVersions})
+			ct.out = io.Discard
+			chart := &Chart{yaml: &util.ChartYaml{Name: "foo", KubeVersion: testData.kubeVersion}}
+
+			versions, err := ct.kubeVersionsToCheck(chart)
+			require.NoError(t, err)
+			var actual []string
+			for _, version := range versions {
+				actual = append(actual, version.Original())
+			}
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestCheckKubeAPIs(t *testing.T) {
+	// The chart switches to batch/v1 for Kubernetes 1.21 and later, but keeps using policy/v1beta1
+	template := func(_ string, _ string, kubeVersion string) (string, error) {
+		cronJobAPI := "batch/v1beta1"
+		if semver.MustParse(kubeVersion).Minor() >= 21 {
+			cronJobAPI = "batch/v1"
+		}
+		return fmt.Sprintf(`---
+# Source: foo/templates/cronjob.yaml
+apiVersion: %s
+kind: CronJob
+metadata:
+  name: backup
+---
+# Source: foo/templates/pdb.yaml
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: foo
+---
+# Source: foo/templates/config.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+`, cronJobAPI), nil
+	}
+
+	var b strings.Builder
+	ct := newTestingMock(config.Configuration{})
+	ct.out = &b
+	ct.helm = &fakeHelm{template: template}
+
+	chart := &Chart{yaml: &util.ChartYaml{Name: "foo", KubeVersion: ">=1.20.0-0 <1.24.0-0"}}
+	assert.NoError(t, ct.CheckKubeAPIs(chart, ""))
+	assert.Contains(t, b.String(), "Warning: policy/v1beta1 PodDisruptionBudget \"foo\" (foo/templates/pdb.yaml) uses an API "+
+		"version deprecated since Kubernetes 1.21\n")
+
+	ct.config.KubeVersions = []string{"1.26"}
+	chart.yaml.KubeVersion = ">=1.20.0-0"
+	b.Reset()
+	err := ct.CheckKubeAPIs(chart, "")
+	assert.EqualError(t, err, "policy/v1beta1 PodDisruptionBudget \"foo\" (foo/templates/pdb.yaml) is not available in "+
+		"Kubernetes 1.25.0, 1.26, 1.27.0, 1.29.0, 1.30.0, 1.32.0, 1.35.0: removed in 1.25, use policy/v1")
+	assert.Contains(t, b.String(), "Checking API versions for Kubernetes 1.20.0...\n")
+	assert.NotContains(t, b.String(), "CronJob")
+}
+
+func TestLintChartStopsCheckingKubeAPIsAtFirstFailure(t *testing.T) {
+	chart, err := NewChart("testdata/values_schema")
+	require.NoError(t, err)
+
+	ct := newTestingMock(config.Configuration{KubeVersions: []string{"1.30"}})
+	ct.out = io.Discard
+	ct.helm = &fakeHelm{template: func(string, string, string) (string, error) {
+		return "", fmt.Errorf("rendering failed")
+	}}
+
+	result := ct.LintChart(chart)
+	assert.ErrorContains(t, result.Error, "rendering failed")
+	require.Len(t, result.Steps, 1)
+	assert.Equal(t, StepKubeAPIs, result.Steps[0].Name)
+}
+
+func TestParseManifests(t *testing.T) {
+	objects, err := parseManifests("---\n# Source: foo/templates/a.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n" +
+		"---\n# Source: foo/templates/empty.yaml\n\n---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: b\n")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "foo/templates/a.yaml", objects[0].Source)
+	assert.Equal(t, `v1 ConfigMap "a"`, objects[0].String())
+	assert.Equal(t, `apps/v1 Deployment "b"`, objects[1].String())
+}
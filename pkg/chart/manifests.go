@@ -0,0 +1,73 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const sourceCommentPrefix = "# Source: "
+
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// manifestObject is a Kubernetes object rendered from a chart template.
+type manifestObject struct {
+	// Source is the template the object has been rendered from
+	Source     string
+	APIVersion string
+	Kind       string
+	Name       string
+	Object     map[string]any
+}
+
+// String returns the object as "apiVersion kind name", e.g. 'apps/v1 Deployment "web"'.
+func (o manifestObject) String() string {
+	return fmt.Sprintf("%s %s %q", o.APIVersion, o.Kind, o.Name)
+}
+
+// parseManifests parses the objects of the YAML documents rendered by `helm template`. Empty documents are skipped.
+func parseManifests(manifests string) ([]manifestObject, error) {
+	var objects []manifestObject
+	for _, document := range documentSeparator.Split(manifests, -1) {
+		var source string
+		for _, line := range strings.Split(document, "\n") {
+			if strings.HasPrefix(line, sourceCommentPrefix) {
+				source = strings.TrimPrefix(line, sourceCommentPrefix)
+				break
+			}
+		}
+
+		var object map[string]any
+		if err := yaml.Unmarshal([]byte(document), &object); err != nil {
+			return nil, fmt.Errorf("failed parsing manifest %s: %w", source, err)
+		}
+		if len(object) == 0 {
+			continue
+		}
+
+		o := manifestObject{Source: source, Object: object}
+		o.APIVersion, _ = object["apiVersion"].(string)
+		o.Kind, _ = object["kind"].(string)
+		if metadata, ok := object["metadata"].(map[string]any); ok {
+			o.Name, _ = metadata["name"].(string)
+		}
+		objects = append(objects, o)
+	}
+	return objects, nil
+}
@@ -0,0 +1,282 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+const crdsDirName = "crds"
+
+// manifestSchemas resolves the JSON schemas rendered objects are validated against. Schemas of custom resources are
+// compiled from the CRDs in the chart's 'crds' directory; all other schemas are looked up in a schema directory.
+type manifestSchemas struct {
+	dir      string
+	compiler *jsonschema.Compiler
+	crds     map[string]*jsonschema.Schema
+	files    map[string]*jsonschema.Schema
+}
+
+// newManifestSchemas creates manifestSchemas for the chart, looking up schemas in schemaDir.
+func newManifestSchemas(chart *Chart, schemaDir string) (*manifestSchemas, error) {
+	s := &manifestSchemas{
+		dir:      schemaDir,
+		compiler: jsonschema.NewCompiler(),
+		crds:     map[string]*jsonschema.Schema{},
+		files:    map[string]*jsonschema.Schema{},
+	}
+	if err := s.loadCRDs(filepath.Join(chart.Path(), crdsDirName)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadCRDs compiles the 'openAPIV3Schema' of every version of the CRDs found in dir.
+func (s *manifestSchemas) loadCRDs(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if d.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed reading CRD file: %w", err)
+		}
+		objects, err := parseManifests(string(data))
+		if err != nil {
+			return fmt.Errorf("failed parsing CRD file %s: %w", path, err)
+		}
+		for _, object := range objects {
+			if object.Kind != "CustomResourceDefinition" {
+				continue
+			}
+			if err := s.addCRD(path, object); err != nil {
+				return fmt.Errorf("failed loading CRD %q from %s: %w", object.Name, path, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *manifestSchemas) addCRD(path string, crd manifestObject) error {
+	spec, _ := crd.Object["spec"].(map[string]any)
+	group, _ := spec["group"].(string)
+	names, _ := spec["names"].(map[string]any)
+	kind, _ := names["kind"].(string)
+	versions, _ := spec["versions"].([]any)
+
+	for _, v := range versions {
+		version, _ := v.(map[string]any)
+		name, _ := version["name"].(string)
+		schema, _ := version["schema"].(map[string]any)
+		openAPISchema, ok := schema["openAPIV3Schema"].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		doc, err := toJSONInstance(withObjectMeta(strictSchema(openAPISchema)))
+		if err != nil {
+			return err
+		}
+		// Each version is registered under a location of its own, derived from the file the CRD is defined in
+		location, err := filepath.Abs(filepath.Join(path, group, fmt.Sprintf("%s_%s.json", strings.ToLower(kind), name)))
+		if err != nil {
+			return err
+		}
+		if err := s.compiler.AddResource(location, doc); err != nil {
+			return err
+		}
+		compiled, err := s.compiler.Compile(location)
+		if err != nil {
+			return err
+		}
+		s.crds[schemaKey(group+"/"+name, kind)] = compiled
+	}
+	return nil
+}
+
+// lookup returns the schema for the given object or nil if there is none.
+func (s *manifestSchemas) lookup(object manifestObject) (*jsonschema.Schema, error) {
+	key := schemaKey(object.APIVersion, object.Kind)
+	if schema, ok := s.crds[key]; ok {
+		return schema, nil
+	}
+	if schema, ok := s.files[key]; ok {
+		return schema, nil
+	}
+
+	for _, candidate := range schemaFileCandidates(object.APIVersion, object.Kind) {
+		path, err := filepath.Abs(filepath.Join(s.dir, candidate))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		schema, err := s.compiler.Compile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed compiling schema %s: %w", path, err)
+		}
+		s.files[key] = schema
+		return schema, nil
+	}
+	s.files[key] = nil
+	return nil, nil
+}
+
+// schemaFileCandidates returns the file names a schema is looked up by in the schema directory, e.g.
+// 'deployment-apps-v1.json' as in kubernetes-json-schema or 'monitoring.coreos.com/servicemonitor_v1.json' as in
+// the CRDs catalog used with kubeconform.
+func schemaFileCandidates(apiVersion string, kind string) []string {
+	kind = strings.ToLower(kind)
+	group, version, found := strings.Cut(apiVersion, "/")
+	if !found {
+		return []string{fmt.Sprintf("%s-%s.json", kind, apiVersion)}
+	}
+	shortGroup, _, _ := strings.Cut(group, ".")
+	return []string{
+		fmt.Sprintf("%s-%s-%s.json", kind, shortGroup, version),
+		filepath.Join(group, fmt.Sprintf("%s_%s.json", kind, version)),
+	}
+}
+
+func schemaKey(apiVersion string, kind string) string {
+	return apiVersion + "/" + kind
+}
+
+// strictSchema returns a copy of an OpenAPI schema which disallows fields not declared in the schema, just like the
+// API server does with strict field validation, unless unknown fields are explicitly preserved.
+func strictSchema(schema map[string]any) map[string]any {
+	result := make(map[string]any, len(schema))
+	for key, value := range schema {
+		result[key] = value
+	}
+
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		strictProperties := make(map[string]any, len(properties))
+		for name, property := range properties {
+			if propertySchema, ok := property.(map[string]any); ok {
+				property = strictSchema(propertySchema)
+			}
+			strictProperties[name] = property
+		}
+		result["properties"] = strictProperties
+
+		_, hasAdditionalProperties := schema["additionalProperties"]
+		preserveUnknownFields, _ := schema["x-kubernetes-preserve-unknown-fields"].(bool)
+		if !hasAdditionalProperties && !preserveUnknownFields {
+			result["additionalProperties"] = false
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties"} {
+		if subSchema, ok := schema[key].(map[string]any); ok {
+			result[key] = strictSchema(subSchema)
+		}
+	}
+	return result
+}
+
+// withObjectMeta adds the fields every object has to the top level properties of a CRD schema, as they may be omitted
+// in CRDs.
+func withObjectMeta(schema map[string]any) map[string]any {
+	properties, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return schema
+	}
+	for name, fieldType := range map[string]string{"apiVersion": "string", "kind": "string", "metadata": "object"} {
+		if _, ok := properties[name]; !ok {
+			properties[name] = map[string]any{"type": fieldType}
+		}
+	}
+	return schema
+}
+
+// toJSONInstance converts a value decoded from YAML to the types produced by JSON decoding, which the validator
+// expects.
+func toJSONInstance(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed converting to JSON: %w", err)
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(data))
+}
+
+// ValidateManifests renders the chart with the given values file and validates every object against its JSON schema
+// from the chart's CRDs or '--schema-dir'. Objects without schema fail validation unless '--ignore-missing-schemas'
+// is set.
+func (t *Testing) ValidateManifests(chart *Chart, valuesFile string) error {
+	fmt.Fprintf(t.out, "Validating manifests against schemas in %s...\n", t.config.SchemaDir)
+
+	manifests, err := t.helm.Template(chart.Path(), valuesFile, "")
+	if err != nil {
+		return fmt.Errorf("failed rendering chart: %w", err)
+	}
+	objects, err := parseManifests(manifests)
+	if err != nil {
+		return err
+	}
+	schemas, err := newManifestSchemas(chart, t.config.SchemaDir)
+	if err != nil {
+		return err
+	}
+
+	var violations int
+	for _, object := range objects {
+		schema, err := schemas.lookup(object)
+		if err != nil {
+			return err
+		}
+		if schema == nil {
+			if !t.config.IgnoreMissingSchemas {
+				return fmt.Errorf("no schema found for %s (%s)", object, object.Source)
+			}
+			fmt.Fprintf(t.out, "Skipping %s (%s): no schema found\n", object, object.Source)
+			continue
+		}
+
+		instance, err := toJSONInstance(object.Object)
+		if err != nil {
+			return err
+		}
+		objectViolations, err := validateInstance(schema, instance)
+		if err != nil {
+			return fmt.Errorf("failed validating %s: %w", object, err)
+		}
+		for _, violation := range objectViolations {
+			fmt.Fprintf(t.out, "%s (%s): %s\n", object, object.Source, violation)
+		}
+		violations += len(objectViolations)
+	}
+
+	if violations > 0 {
+		return fmt.Errorf("rendered manifests do not match their schemas: found %d violation(s)", violations)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helm/chart-testing/v3/pkg/config"
+)
+
+func TestValidateManifests(t *testing.T) {
+	var testDataSet = []struct {
+		name                 string
+		manifests            string
+		ignoreMissingSchemas bool
+		expectedErr          string
+		expectedOutput       []string
+	}{
+		{
+			"valid objects",
+			`---
+# Source: widgets/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  labels:
+    app: widgets
+data:
+  key: value
+---
+# Source: widgets/templates/widget.yaml
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+spec:
+  size: 3
+  labels:
+    tier: small
+  extra:
+    anything: goes
+`,
+			false,
+			"",
+			nil,
+		},
+		{
+			"misspelled fields and wrong types",
+			`---
+# Source: widgets/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  lables:
+    app: widgets
+data:
+  replicas: 3
+---
+# Source: widgets/templates/widget.yaml
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+spec:
+  sise: 3
+`,
+			false,
+			"rendered manifests do not match their schemas: found 4 violation(s)",
+			[]string{
+				`v1 ConfigMap "config" (widgets/templates/configmap.yaml): /data/replicas: got number, want string`,
+				`v1 ConfigMap "config" (widgets/templates/configmap.yaml): /metadata: additional properties 'lables' not allowed`,
+				`example.com/v1 Widget "widget" (widgets/templates/widget.yaml): /spec: additional properties 'sise' not allowed`,
+				`example.com/v1 Widget "widget" (widgets/templates/widget.yaml): /spec: missing property 'size'`,
+			},
+		},
+		{
+			"missing schema",
+			`---
+# Source: widgets/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: widgets
+`,
+			false,
+			`no schema found for apps/v1 Deployment "widgets" (widgets/templates/deployment.yaml)`,
+			nil,
+		},
+		{
+			"ignored missing schema",
+			`---
+# Source: widgets/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: widgets
+`,
+			true,
+			"",
+			[]string{`Skipping apps/v1 Deployment "widgets" (widgets/templates/deployment.yaml): no schema found`},
+		},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			var b strings.Builder
+			ct := newTestingMock(config.Configuration{
+				SchemaDir:            "testdata/manifest_schema/schemas",
+				IgnoreMissingSchemas: testData.ignoreMissingSchemas,
+			})
+			ct.out = &b
+			ct.helm = &fakeHelm{template: func(string, string, string) (string, error) {
+				return testData.manifests, nil
+			}}
+			chart := &Chart{path: "testdata/manifest_schema/chart"}
+
+			err := ct.ValidateManifests(chart, "")
+			if testData.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testData.expectedErr)
+			}
+			for _, line := range testData.expectedOutput {
+				assert.Contains(t, b.String(), line+"\n")
+			}
+		})
+	}
+}
+
+func TestLintChartStopsValidatingManifestsAtFirstFailure(t *testing.T) {
+	chart, err := NewChart("testdata/values_schema")
+	require.NoError(t, err)
+
+	ct := newTestingMock(config.Configuration{SchemaDir: "testdata/manifest_schema/schemas"})
+	ct.out = io.Discard
+	ct.helm = &fakeHelm{template: func(string, string, string) (string, error) {
+		return "", errors.New("rendering failed")
+	}}
+
+	result := ct.LintChart(chart)
+	assert.ErrorContains(t, result.Error, "rendering failed")
+	require.Len(t, result.Steps, 1)
+	assert.Equal(t, StepManifestSchema, result.Steps[0].Name)
+}
+
+func TestSchemaFileCandidates(t *testing.T) {
+	assert.Equal(t, []string{"configmap-v1.json"}, schemaFileCandidates("v1", "ConfigMap"))
+	assert.Equal(t, []string{"ingress-networking-v1.json", "networking.k8s.io/ingress_v1.json"},
+		schemaFileCandidates("networking.k8s.io/v1", "Ingress"))
+}
@@ -0,0 +1,132 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/helm/chart-testing/v3/pkg/config"
+)
+
+const (
+	// profileObjectName is the name of the objects created for a namespace profile.
+	profileObjectName = "chart-testing"
+	profileAnnotation = annotationPrefix + "namespace-profile"
+)
+
+// podSecurityModes are the modes of Pod Security admission, each of which is set to the level of a profile. Besides
+// rejecting non-compliant pods, this warns about workloads whose pod template is not compliant on install.
+var podSecurityModes = []string{"enforce", "audit", "warn"}
+
+// namespaceProfile returns the labels a namespace profile adds to a namespace and the objects it creates in it.
+func namespaceProfile(profile config.NamespaceProfile) (labels map[string]string, objects []runtime.Object, err error) {
+	labels = map[string]string{}
+	if profile.PodSecurity != "" {
+		for _, mode := range podSecurityModes {
+			labels["pod-security.kubernetes.io/"+mode] = profile.PodSecurity
+		}
+	}
+
+	objectMeta := metav1.ObjectMeta{
+		Name:   profileObjectName,
+		Labels: map[string]string{managedByLabel: managedByValue},
+	}
+
+	if len(profile.ResourceQuota) > 0 {
+		hard, err := resourceList("resource-quota", profile.ResourceQuota)
+		if err != nil {
+			return nil, nil, err
+		}
+		objects = append(objects, &corev1.ResourceQuota{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ResourceQuota"},
+			ObjectMeta: objectMeta,
+			Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+		})
+	}
+
+	if !profile.LimitRange.IsEmpty() {
+		limit := corev1.LimitRangeItem{Type: corev1.LimitTypeContainer}
+		for _, field := range []struct {
+			name      string
+			resources map[string]string
+			list      *corev1.ResourceList
+		}{
+			{"limit-range.default", profile.LimitRange.Default, &limit.Default},
+			{"limit-range.default-request", profile.LimitRange.DefaultRequest, &limit.DefaultRequest},
+			{"limit-range.min", profile.LimitRange.Min, &limit.Min},
+			{"limit-range.max", profile.LimitRange.Max, &limit.Max},
+		} {
+			if *field.list, err = resourceList(field.name, field.resources); err != nil {
+				return nil, nil, err
+			}
+		}
+		objects = append(objects, &corev1.LimitRange{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "LimitRange"},
+			ObjectMeta: objectMeta,
+			Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{limit}},
+		})
+	}
+
+	if profile.DefaultDenyNetworkPolicy {
+		// Selecting all pods denies all traffic but DNS lookups, without which hardly any chart would pass, e.g. because
+		// its test pods cannot resolve the chart's services
+		udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+		dnsPort := intstr.FromInt32(53)
+		objects = append(objects, &networkingv1.NetworkPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+			ObjectMeta: objectMeta,
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+				Egress: []networkingv1.NetworkPolicyEgressRule{{
+					To: []networkingv1.NetworkPolicyPeer{{
+						NamespaceSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{corev1.LabelMetadataName: "kube-system"},
+						},
+					}},
+					Ports: []networkingv1.NetworkPolicyPort{
+						{Protocol: &udp, Port: &dnsPort},
+						{Protocol: &tcp, Port: &dnsPort},
+					},
+				}},
+			},
+		})
+	}
+
+	return labels, objects, nil
+}
+
+// resourceList parses the quantities of the resources configured with setting.
+func resourceList(setting string, resources map[string]string) (corev1.ResourceList, error) {
+	if len(resources) == 0 {
+		return nil, nil
+	}
+	list := corev1.ResourceList{}
+	for name, value := range resources {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q of %q in %q: %w", value, name, setting, err)
+		}
+		list[corev1.ResourceName(name)] = quantity
+	}
+	return list, nil
+}
@@ -0,0 +1,133 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/helm/chart-testing/v3/pkg/config"
+	"github.com/helm/chart-testing/v3/pkg/tool"
+	"github.com/helm/chart-testing/v3/pkg/util"
+)
+
+func TestCreateNamespaceWithProfile(t *testing.T) {
+	client := fake.NewClientset()
+	kubeClient := func() (kubernetes.Interface, error) { return client, nil }
+	ct := newTestingMock(config.Configuration{
+		NamespaceProfile: "restricted",
+		NamespaceProfiles: map[string]config.NamespaceProfile{
+			"restricted": {
+				PodSecurity:   "restricted",
+				ResourceQuota: map[string]string{"requests.cpu": "2", "pods": "10"},
+				LimitRange: config.LimitRange{
+					Default:        map[string]string{"memory": "256Mi"},
+					DefaultRequest: map[string]string{"cpu": "100m"},
+				},
+				DefaultDenyNetworkPolicy: true,
+			},
+		},
+	})
+	ct.out = io.Discard
+	ct.kubectl = tool.NewClientGoKubectl(kubeClient, io.Discard, time.Second, time.Second)
+
+	chart := &Chart{path: "charts/foo", yaml: &util.ChartYaml{Name: "foo", Version: "1.2.0"}}
+	require.NoError(t, ct.createNamespace(chart, "foo"))
+
+	ctx := context.Background()
+	namespace, err := client.CoreV1().Namespaces().Get(ctx, "foo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"app.kubernetes.io/managed-by":       "chart-testing",
+		"pod-security.kubernetes.io/enforce": "restricted",
+		"pod-security.kubernetes.io/audit":   "restricted",
+		"pod-security.kubernetes.io/warn":    "restricted",
+	}, namespace.Labels)
+	assert.Equal(t, "restricted", namespace.Annotations["chart-testing.helm.sh/namespace-profile"])
+
+	quota, err := client.CoreV1().ResourceQuotas("foo").Get(ctx, "chart-testing", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ResourceList{
+		"requests.cpu": resource.MustParse("2"),
+		"pods":         resource.MustParse("10"),
+	}, quota.Spec.Hard)
+
+	limitRange, err := client.CoreV1().LimitRanges("foo").Get(ctx, "chart-testing", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []corev1.LimitRangeItem{{
+		Type:           corev1.LimitTypeContainer,
+		Default:        corev1.ResourceList{"memory": resource.MustParse("256Mi")},
+		DefaultRequest: corev1.ResourceList{"cpu": resource.MustParse("100m")},
+	}}, limitRange.Spec.Limits)
+
+	policy, err := client.NetworkingV1().NetworkPolicies("foo").Get(ctx, "chart-testing", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, policy.Spec.PodSelector.MatchLabels)
+	assert.Empty(t, policy.Spec.Ingress)
+	assert.Len(t, policy.Spec.PolicyTypes, 2)
+	// Only DNS lookups are allowed
+	require.Len(t, policy.Spec.Egress, 1)
+	assert.Equal(t, "kube-system", policy.Spec.Egress[0].To[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+	assert.Equal(t, 53, policy.Spec.Egress[0].Ports[0].Port.IntValue())
+}
+
+func TestNamespaceProfile(t *testing.T) {
+	var testDataSet = []struct {
+		name            string
+		profile         config.NamespaceProfile
+		expectedLabels  int
+		expectedObjects int
+		expectedError   string
+	}{
+		{"empty", config.NamespaceProfile{}, 0, 0, ""},
+		{"pod security only", config.NamespaceProfile{PodSecurity: "baseline"}, 3, 0, ""},
+		{"network policy only", config.NamespaceProfile{DefaultDenyNetworkPolicy: true}, 0, 1, ""},
+		{
+			"invalid quota",
+			config.NamespaceProfile{ResourceQuota: map[string]string{"requests.cpu": "two"}},
+			0, 0,
+			`invalid quantity "two" of "requests.cpu" in "resource-quota"`,
+		},
+		{
+			"invalid limit",
+			config.NamespaceProfile{LimitRange: config.LimitRange{Max: map[string]string{"memory": "1 Gi"}}},
+			0, 0,
+			`invalid quantity "1 Gi" of "memory" in "limit-range.max"`,
+		},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			labels, objects, err := namespaceProfile(testData.profile)
+			if testData.expectedError != "" {
+				assert.ErrorContains(t, err, testData.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, labels, testData.expectedLabels)
+			assert.Len(t, objects, testData.expectedObjects)
+		})
+	}
+}
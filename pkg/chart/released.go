@@ -0,0 +1,324 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/helm/chart-testing/v3/pkg/util"
+)
+
+const (
+	// upgradeSourceMergeBase tests upgrades from the chart's revision at the merge base with the target branch.
+	upgradeSourceMergeBase = "merge-base"
+	// upgradeSourceGitTags tests upgrades from the chart's revisions tagged '<chart>-<version>'.
+	upgradeSourceGitTags = "git-tags"
+	ociPrefix            = "oci://"
+)
+
+// releasedChart is a released version of a chart which upgrades can be tested from.
+type releasedChart struct {
+	version *semver.Version
+	// fetch makes the chart available in dir and returns its path and a function which cleans up resources
+	// other than dir, if any
+	fetch func(dir string) (path string, cleanup func(), err error)
+}
+
+// chartIndex is the part of a chart repository's 'index.yaml' needed to find released versions.
+type chartIndex struct {
+	Entries map[string][]struct {
+		Version string   `yaml:"version"`
+		URLs    []string `yaml:"urls"`
+	} `yaml:"entries"`
+}
+
+// upgradeSources returns the sources of the versions upgrades are tested from, which default to the merge base.
+func (t *Testing) upgradeSources() []string {
+	if len(t.config.UpgradeFrom) == 0 {
+		return []string{upgradeSourceMergeBase}
+	}
+	return t.config.UpgradeFrom
+}
+
+// upgradeFromReleases tests upgrades of chart from the latest released versions found in source. Each version is
+// upgraded to chart in a release of its own or, if '--upgrade-chain' is set, all versions are upgraded in turn in a
+// single release.
+func (t *Testing) upgradeFromReleases(result *TestResult, chart *Chart, source string) {
+	fmt.Fprintf(t.out, "Looking up released versions of chart %q in %s...\n", chart, source)
+	releases, err := t.listReleasedCharts(chart, source)
+	if err != nil {
+		result.runStep(StepUpgradeReleased, "", func() error { return err }) // nolint: errcheck
+		return
+	}
+	releases = selectReleasedCharts(releases, chart.Yaml().Version, t.config.UpgradeVersions)
+	if len(releases) == 0 {
+		fmt.Fprintf(t.out, "Skipping upgrade test of %q from %s because: no released versions to upgrade from\n", chart, source)
+		result.skipStep(StepUpgradeReleased, "")
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "ct-released")
+	if err != nil {
+		result.runStep(StepUpgradeReleased, "", func() error { // nolint: errcheck
+			return fmt.Errorf("could not create directory for released versions: %w", err)
+		})
+		return
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	var oldCharts []*Chart
+	for _, release := range releases {
+		versionDir := filepath.Join(dir, release.version.String())
+		if err := os.Mkdir(versionDir, 0755); err != nil {
+			result.runStep(StepUpgradeReleased, "", func() error { return err }) // nolint: errcheck
+			return
+		}
+		path, cleanup, err := release.fetch(versionDir)
+		if cleanup != nil {
+			defer cleanup()
+		}
+		if err == nil {
+			var oldChart *Chart
+			if oldChart, err = NewChart(path); err == nil {
+				oldCharts = append(oldCharts, oldChart)
+				continue
+			}
+		}
+		result.runStep(StepUpgradeReleased, "", func() error { // nolint: errcheck
+			return fmt.Errorf("failed fetching version %s of chart %q from %s: %w", release.version, chart.Yaml().Name, source, err)
+		})
+		return
+	}
+
+	if t.config.UpgradeChain {
+		t.doUpgradeChain(result, StepUpgradeReleased, oldCharts, chart, false) // nolint: errcheck
+		return
+	}
+	for _, oldChart := range oldCharts {
+		t.doUpgradeChain(result, StepUpgradeReleased, []*Chart{oldChart}, chart, false) // nolint: errcheck
+	}
+}
+
+// listReleasedCharts returns the released versions of chart found in source, which is either 'git-tags', an OCI
+// repository ('oci://...') the chart is pushed to, or the location (URL or path) of a chart repository's index.
+func (t *Testing) listReleasedCharts(chart *Chart, source string) ([]releasedChart, error) {
+	switch {
+	case source == upgradeSourceGitTags:
+		return t.listGitTagReleases(chart)
+	case strings.HasPrefix(source, ociPrefix):
+		return t.listOCIReleases(chart, source)
+	default:
+		return t.listIndexReleases(chart, source)
+	}
+}
+
+// listGitTagReleases returns the revisions of chart tagged '<chart>-<version>'.
+func (t *Testing) listGitTagReleases(chart *Chart) ([]releasedChart, error) {
+	prefix := chart.Yaml().Name + "-"
+	tags, err := t.git.ListTags(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []releasedChart
+	for _, tag := range tags {
+		version, err := semver.StrictNewVersion(strings.TrimPrefix(tag, prefix))
+		if err != nil {
+			// Tags of other charts sharing the prefix, e.g. 'foo-bar-1.0.0' for chart 'foo'
+			continue
+		}
+		releases = append(releases, releasedChart{
+			version: version,
+			fetch: func(dir string) (string, func(), error) {
+				worktree := filepath.Join(dir, "worktree")
+				if err := t.git.AddWorktree(worktree, tag); err != nil {
+					return "", nil, fmt.Errorf("could not create worktree for tag %q: %w", tag, err)
+				}
+				cleanup := func() {
+					t.uncanceled().git.RemoveWorktree(worktree) // nolint: errcheck
+				}
+				path := filepath.Join(worktree, chart.Path())
+				if !t.config.SkipHelmDependencies {
+					if err := t.helm.BuildDependenciesWithArgs(path, t.config.HelmDependencyExtraArgs); err != nil {
+						return "", cleanup, err
+					}
+				}
+				return path, cleanup, nil
+			},
+		})
+	}
+	return releases, nil
+}
+
+// listOCIReleases returns the versions of chart pushed to the OCI repository source.
+func (t *Testing) listOCIReleases(chart *Chart, source string) ([]releasedChart, error) {
+	ref := strings.TrimSuffix(source, "/") + "/" + chart.Yaml().Name
+	tags, err := t.listOCITags(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []releasedChart
+	for _, tag := range tags {
+		version, err := semver.StrictNewVersion(tag)
+		if err != nil {
+			continue
+		}
+		releases = append(releases, releasedChart{
+			version: version,
+			fetch: func(dir string) (string, func(), error) {
+				err := t.helm.Pull(ref, tag, dir)
+				return filepath.Join(dir, chart.Yaml().Name), nil, err
+			},
+		})
+	}
+	return releases, nil
+}
+
+// listIndexReleases returns the versions of chart listed in the chart repository index at location, which is a URL
+// or a path. Relative chart URLs are resolved against the location of the index.
+func (t *Testing) listIndexReleases(chart *Chart, location string) ([]releasedChart, error) {
+	data, err := readIndex(location)
+	if err != nil {
+		return nil, err
+	}
+	var index chartIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed parsing index %s: %w", location, err)
+	}
+
+	var releases []releasedChart
+	for _, entry := range index.Entries[chart.Yaml().Name] {
+		version, err := semver.StrictNewVersion(entry.Version)
+		if err != nil || len(entry.URLs) == 0 {
+			continue
+		}
+		chartURL := resolveChartURL(location, entry.URLs[0])
+		releases = append(releases, releasedChart{
+			version: version,
+			fetch: func(dir string) (string, func(), error) {
+				var err error
+				if isHTTPURL(chartURL) {
+					err = t.helm.Pull(chartURL, "", dir)
+				} else {
+					err = chartutil.ExpandFile(dir, chartURL)
+				}
+				return filepath.Join(dir, chart.Yaml().Name), nil, err
+			},
+		})
+	}
+	return releases, nil
+}
+
+// selectReleasedCharts returns the latest n of the released charts, oldest first, which are older than version and
+// can be upgraded to version without breaking change according to the SemVer specification. Pre-releases are ignored.
+func selectReleasedCharts(releases []releasedChart, version string, n int) []releasedChart {
+	current, err := semver.NewVersion(version)
+	if err != nil {
+		return nil
+	}
+
+	var selected []releasedChart
+	for _, release := range releases {
+		if release.version.Prerelease() != "" || !release.version.LessThan(current) {
+			continue
+		}
+		if breaking, _ := util.BreakingChangeAllowed(release.version.String(), version); breaking {
+			continue
+		}
+		if slices.ContainsFunc(selected, func(r releasedChart) bool { return r.version.Equal(release.version) }) {
+			continue
+		}
+		selected = append(selected, release)
+	}
+
+	slices.SortFunc(selected, func(a, b releasedChart) int {
+		return a.version.Compare(b.version)
+	})
+	if len(selected) > n {
+		selected = selected[len(selected)-n:]
+	}
+	return selected
+}
+
+func readIndex(location string) ([]byte, error) {
+	if !isHTTPURL(location) {
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading index: %w", err)
+		}
+		return data, nil
+	}
+
+	response, err := http.Get(location) // nolint: gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed downloading index: %w", err)
+	}
+	defer response.Body.Close() // nolint: errcheck
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed downloading index %s: %s", location, response.Status)
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed downloading index: %w", err)
+	}
+	return data, nil
+}
+
+// resolveChartURL resolves the URL of a chart archive listed in the index at location.
+func resolveChartURL(location string, chartURL string) string {
+	if isHTTPURL(chartURL) {
+		return chartURL
+	}
+	if isHTTPURL(location) {
+		base, err := url.Parse(location)
+		if err != nil {
+			return chartURL
+		}
+		ref, err := url.Parse(chartURL)
+		if err != nil {
+			return chartURL
+		}
+		return base.ResolveReference(ref).String()
+	}
+	if filepath.IsAbs(chartURL) {
+		return chartURL
+	}
+	return filepath.Join(filepath.Dir(location), chartURL)
+}
+
+func isHTTPURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// upgradePath returns the versions of the charts an upgrade is tested from, e.g. '1.0.0 -> 1.1.0'.
+func upgradePath(charts []*Chart) string {
+	versions := make([]string, len(charts))
+	for i, chart := range charts {
+		versions[i] = chart.Yaml().Version
+	}
+	return strings.Join(versions, " -> ")
+}
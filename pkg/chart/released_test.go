@@ -0,0 +1,192 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/helm/chart-testing/v3/pkg/config"
+	"github.com/helm/chart-testing/v3/pkg/tool"
+	"github.com/helm/chart-testing/v3/pkg/util"
+)
+
+// writeChartRepository writes an index listing the given versions of chart 'foo' along with their archives to dir.
+func writeChartRepository(t *testing.T, dir string, versions ...string) string {
+	index := "apiVersion: v1\nentries:\n  foo:\n"
+	for _, version := range versions {
+		archive := fmt.Sprintf("foo-%s.tgz", version)
+		index += fmt.Sprintf("    - name: foo\n      version: %s\n      urls:\n        - charts/%s\n", version, archive)
+
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "charts"), 0755))
+		file, err := os.Create(filepath.Join(dir, "charts", archive))
+		require.NoError(t, err)
+		gzipWriter := gzip.NewWriter(file)
+		tarWriter := tar.NewWriter(gzipWriter)
+		chartYaml := fmt.Sprintf("apiVersion: v2\nname: foo\nversion: %s\n", version)
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "foo/Chart.yaml", Mode: 0644, Size: int64(len(chartYaml))}))
+		_, err = tarWriter.Write([]byte(chartYaml))
+		require.NoError(t, err)
+		require.NoError(t, tarWriter.Close())
+		require.NoError(t, gzipWriter.Close())
+		require.NoError(t, file.Close())
+	}
+
+	indexFile := filepath.Join(dir, "index.yaml")
+	require.NoError(t, os.WriteFile(indexFile, []byte(index), 0644))
+	return indexFile
+}
+
+func TestUpgradeFromReleases(t *testing.T) {
+	indexFile := writeChartRepository(t, t.TempDir(), "0.9.0", "1.0.0", "1.1.0", "1.2.0-rc.1", "2.0.0")
+
+	var testDataSet = []struct {
+		name          string
+		chart         string
+		upgradeChain  bool
+		rollback      bool
+		expectedSteps []string
+		expectedCalls []string
+	}{
+		{
+			"each version separately",
+			"foo",
+			false,
+			false,
+			[]string{"upgrade-released from 1.0.0", "upgrade-released from 1.1.0"},
+			[]string{"install 1.0.0", "upgrade current", "install 1.1.0", "upgrade current"},
+		},
+		{
+			"upgrade chain",
+			"foo",
+			true,
+			false,
+			[]string{"upgrade-released from 1.0.0 -> 1.1.0"},
+			[]string{"install 1.0.0", "upgrade 1.1.0", "upgrade current"},
+		},
+		{
+			"upgrade chain with rollback",
+			"foo",
+			true,
+			true,
+			[]string{"upgrade-released from 1.0.0 -> 1.1.0", "rollback from 1.0.0 -> 1.1.0"},
+			[]string{"install 1.0.0", "upgrade 1.1.0", "upgrade current", "rollback"},
+		},
+		{
+			"no released versions",
+			"bar",
+			false,
+			true,
+			[]string{"upgrade-released"},
+			nil,
+		},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			client := fake.NewClientset()
+			kubeClient := func() (kubernetes.Interface, error) { return client, nil }
+			ct := newTestingMock(config.Configuration{
+				UpgradeFrom:     []string{indexFile},
+				UpgradeVersions: 2,
+				UpgradeChain:    testData.upgradeChain,
+				Rollback:        testData.rollback,
+				SkipCleanUp:     true,
+			})
+			ct.out = io.Discard
+			helm := new(fakeHelm)
+			ct.helm = helm
+			ct.kubectl = tool.NewClientGoKubectl(kubeClient, io.Discard, time.Second, time.Second)
+
+			chart := &Chart{path: "current", yaml: &util.ChartYaml{Name: testData.chart, Version: "1.2.0"}}
+			result := TestResult{Chart: chart}
+			ct.upgradeChart(&result, chart)
+			require.NoError(t, result.Error)
+
+			var steps []string
+			for _, step := range result.Steps {
+				steps = append(steps, step.displayName())
+			}
+			assert.Equal(t, testData.expectedSteps, steps)
+
+			// Released versions are unpacked into a directory per version
+			var calls []string
+			for _, call := range helm.calls {
+				action, path, found := strings.Cut(call, " ")
+				if !found {
+					calls = append(calls, action)
+					continue
+				}
+				if path != "current" {
+					path = filepath.Base(filepath.Dir(path))
+				}
+				calls = append(calls, action+" "+path)
+			}
+			assert.Equal(t, testData.expectedCalls, calls)
+		})
+	}
+}
+
+func TestListReleasedCharts(t *testing.T) {
+	ct := newTestingMock(config.Configuration{})
+	ct.listOCITags = func(ref string) ([]string, error) {
+		assert.Equal(t, "oci://ghcr.io/org/charts/foo", ref)
+		return []string{"1.1.0", "1.0.0+build.1", "latest"}, nil
+	}
+	chart := &Chart{path: "charts/foo", yaml: &util.ChartYaml{Name: "foo", Version: "1.2.0"}}
+
+	versions := func(source string) []string {
+		releases, err := ct.listReleasedCharts(chart, source)
+		require.NoError(t, err)
+		var versions []string
+		for _, release := range releases {
+			versions = append(versions, release.version.String())
+		}
+		return versions
+	}
+	assert.Equal(t, []string{"0.9.0", "1.0.0", "1.1.0-rc.1", "1.1.0"}, versions(upgradeSourceGitTags))
+	assert.Equal(t, []string{"1.1.0", "1.0.0+build.1"}, versions("oci://ghcr.io/org/charts/"))
+
+	_, err := ct.listReleasedCharts(chart, "missing/index.yaml")
+	assert.ErrorContains(t, err, "failed reading index")
+}
+
+func TestResolveChartURL(t *testing.T) {
+	var testDataSet = []struct {
+		location string
+		chartURL string
+		expected string
+	}{
+		{"https://charts.example.com/index.yaml", "foo-1.0.0.tgz", "https://charts.example.com/foo-1.0.0.tgz"},
+		{"https://charts.example.com/stable/index.yaml", "https://cdn.example.com/foo-1.0.0.tgz", "https://cdn.example.com/foo-1.0.0.tgz"},
+		{"repo/index.yaml", "charts/foo-1.0.0.tgz", filepath.Join("repo", "charts", "foo-1.0.0.tgz")},
+	}
+
+	for _, testData := range testDataSet {
+		assert.Equal(t, testData.expected, resolveChartURL(testData.location, testData.chartURL))
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// resultsFormatVersion is the version of the JSON results document. It must be increased whenever
+// the document changes in an incompatible way.
+const resultsFormatVersion = 1
+
+type resultsDocument struct {
+	Version int           `json:"version"`
+	Results []resultEntry `json:"results"`
+}
+
+// resultEntry holds the result of a single step of a chart. All fields are always present, so consumers
+// do not need to handle missing keys.
+type resultEntry struct {
+	Chart           string  `json:"chart"`
+	Version         string  `json:"version"`
+	Path            string  `json:"path"`
+	KubeContext     string  `json:"kubeContext"`
+	ValuesFile      string  `json:"valuesFile"`
+	Step            string  `json:"step"`
+	UpgradeFrom     string  `json:"upgradeFrom"`
+	StartTime       string  `json:"startTime"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Status          string  `json:"status"`
+	Error           string  `json:"error"`
+	Skipped         bool    `json:"skipped"`
+	Deprecated      bool    `json:"deprecated"`
+	Excluded        bool    `json:"excluded"`
+	TimedOut        bool    `json:"timedOut"`
+	Flaky           bool    `json:"flaky"`
+	Quarantined     bool    `json:"quarantined"`
+}
+
+// WriteResultsFile writes the results as JSON document to the file configured with '--results-file'.
+// Nothing is written if no results file is configured.
+func (t *Testing) WriteResultsFile(results []TestResult) error {
+	if t.config.ResultsFile == "" {
+		return nil
+	}
+
+	file, err := os.Create(t.config.ResultsFile)
+	if err != nil {
+		return fmt.Errorf("failed creating results file: %w", err)
+	}
+	defer file.Close() // nolint: errcheck
+
+	return writeJSONResults(file, results)
+}
+
+func writeJSONResults(w io.Writer, results []TestResult) error {
+	document := resultsDocument{
+		Version: resultsFormatVersion,
+		Results: []resultEntry{},
+	}
+
+	for _, result := range results {
+		for _, step := range resultSteps(result, "") {
+			entry := resultEntry{
+				Chart:           result.Chart.Yaml().Name,
+				Version:         result.Chart.Yaml().Version,
+				Path:            result.Chart.Path(),
+				KubeContext:     result.KubeContext,
+				ValuesFile:      step.ValuesFile,
+				Step:            step.Name,
+				UpgradeFrom:     step.UpgradeFrom,
+				DurationSeconds: step.Duration.Seconds(),
+				Status:          string(step.Status),
+				Skipped:         result.Skipped,
+				Deprecated:      result.Chart.Yaml().Deprecated,
+				Excluded:        result.Excluded,
+				TimedOut:        result.TimedOut,
+				Flaky:           step.Flaky,
+				Quarantined:     result.Quarantined,
+			}
+			if !step.StartTime.IsZero() {
+				entry.StartTime = step.StartTime.UTC().Format(time.RFC3339Nano)
+			}
+			if step.Error != nil {
+				entry.Error = step.Error.Error()
+			}
+			document.Results = append(document.Results, entry)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("failed encoding results: %w", err)
+	}
+	return nil
+}
+
+// resultSteps returns the steps of result. Charts for which no step has been run are represented by a single step
+// with the given name, which reflects the outcome of the chart.
+func resultSteps(result TestResult, name string) []StepResult {
+	if len(result.Steps) > 0 {
+		return result.Steps
+	}
+
+	step := StepResult{Name: name, Status: StatusPassed, Error: result.Error}
+	if result.Skipped {
+		step.Status = StatusSkipped
+	} else if result.Error != nil {
+		step.Status = StatusFailed
+	}
+	return []StepResult{step}
+}
@@ -0,0 +1,149 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/helm/chart-testing/v3/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSONResults(t *testing.T) {
+	results := []TestResult{
+		{
+			Chart:       &Chart{path: "charts/foo", yaml: &util.ChartYaml{Name: "foo", Version: "1.0.0"}},
+			KubeContext: "kind-a",
+			Error:       errors.New("failed waiting for process: exit status 1"),
+			Steps: []StepResult{
+				{
+					Name:       StepInstall,
+					ValuesFile: "charts/foo/ci/a-values.yaml",
+					StartTime:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+					Duration:   1500 * time.Millisecond,
+					Status:     StatusPassed,
+				},
+				{
+					Name:       StepInstall,
+					ValuesFile: "charts/foo/ci/b-values.yaml",
+					StartTime:  time.Date(2024, 5, 1, 12, 0, 1, 500000000, time.UTC),
+					Duration:   2 * time.Second,
+					Status:     StatusFailed,
+					Error:      errors.New("failed waiting for process: exit status 1"),
+				},
+			},
+		},
+		{
+			Chart:   &Chart{path: "charts/bar", yaml: &util.ChartYaml{Name: "bar", Version: "0.1.0", Deprecated: true}},
+			Skipped: true,
+		},
+		{
+			Chart:    &Chart{path: "charts/common", yaml: &util.ChartYaml{Name: "common", Version: "2.0.0"}},
+			Skipped:  true,
+			Excluded: true,
+		},
+	}
+
+	var b strings.Builder
+	err := writeJSONResults(&b, results)
+	assert.Nil(t, err)
+
+	expected := `{
+  "version": 1,
+  "results": [
+    {
+      "chart": "foo",
+      "version": "1.0.0",
+      "path": "charts/foo",
+      "kubeContext": "kind-a",
+      "valuesFile": "charts/foo/ci/a-values.yaml",
+      "step": "install",
+      "upgradeFrom": "",
+      "startTime": "2024-05-01T12:00:00Z",
+      "durationSeconds": 1.5,
+      "status": "passed",
+      "error": "",
+      "skipped": false,
+      "deprecated": false,
+      "excluded": false,
+      "timedOut": false,
+      "flaky": false,
+      "quarantined": false
+    },
+    {
+      "chart": "foo",
+      "version": "1.0.0",
+      "path": "charts/foo",
+      "kubeContext": "kind-a",
+      "valuesFile": "charts/foo/ci/b-values.yaml",
+      "step": "install",
+      "upgradeFrom": "",
+      "startTime": "2024-05-01T12:00:01.5Z",
+      "durationSeconds": 2,
+      "status": "failed",
+      "error": "failed waiting for process: exit status 1",
+      "skipped": false,
+      "deprecated": false,
+      "excluded": false,
+      "timedOut": false,
+      "flaky": false,
+      "quarantined": false
+    },
+    {
+      "chart": "bar",
+      "version": "0.1.0",
+      "path": "charts/bar",
+      "kubeContext": "",
+      "valuesFile": "",
+      "step": "",
+      "upgradeFrom": "",
+      "startTime": "",
+      "durationSeconds": 0,
+      "status": "skipped",
+      "error": "",
+      "skipped": true,
+      "deprecated": true,
+      "excluded": false,
+      "timedOut": false,
+      "flaky": false,
+      "quarantined": false
+    },
+    {
+      "chart": "common",
+      "version": "2.0.0",
+      "path": "charts/common",
+      "kubeContext": "",
+      "valuesFile": "",
+      "step": "",
+      "upgradeFrom": "",
+      "startTime": "",
+      "durationSeconds": 0,
+      "status": "skipped",
+      "error": "",
+      "skipped": true,
+      "deprecated": false,
+      "excluded": true,
+      "timedOut": false,
+      "flaky": false,
+      "quarantined": false
+    }
+  ]
+}
+`
+	assert.Equal(t, expected, b.String())
+}
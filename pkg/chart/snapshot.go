@@ -0,0 +1,202 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	snapshotsDirName = "__snapshots__"
+	// randomValue replaces values which differ between two renderings of a chart, e.g. generated passwords
+	randomValue = "<random>"
+)
+
+// SnapshotsDir returns the directory snapshots of the chart's rendered manifests are stored in.
+func (c *Chart) SnapshotsDir() string {
+	return filepath.Join(c.path, "ci", snapshotsDirName)
+}
+
+// HasSnapshots returns whether the chart has a snapshots directory.
+func (c *Chart) HasSnapshots() bool {
+	info, err := os.Stat(c.SnapshotsDir())
+	return err == nil && info.IsDir()
+}
+
+// CheckSnapshot renders the chart with the given values file and compares the normalized manifests to the chart's
+// snapshot for the values file. Differences are printed as unified diff. If '--update-snapshots' is set, the snapshot
+// is written instead.
+func (t *Testing) CheckSnapshot(chart *Chart, valuesFile string) error {
+	snapshotFile := filepath.Join(chart.SnapshotsDir(), valuesName(valuesFile)+".yaml")
+	fmt.Fprintf(t.out, "Comparing rendered manifests to snapshot %s...\n", snapshotFile)
+
+	actual, err := t.renderSnapshot(chart, valuesFile)
+	if err != nil {
+		return err
+	}
+	expected, err := os.ReadFile(snapshotFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		if !t.config.UpdateSnapshots {
+			return fmt.Errorf("snapshot %s not found: run with '--update-snapshots' to create it", snapshotFile)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed reading snapshot: %w", err)
+	}
+	if string(expected) == actual {
+		return nil
+	}
+
+	if t.config.UpdateSnapshots {
+		if err := os.MkdirAll(chart.SnapshotsDir(), 0755); err != nil {
+			return fmt.Errorf("failed creating snapshots directory: %w", err)
+		}
+		if err := os.WriteFile(snapshotFile, []byte(actual), 0644); err != nil {
+			return fmt.Errorf("failed writing snapshot: %w", err)
+		}
+		fmt.Fprintf(t.out, "Updated snapshot %s\n", snapshotFile)
+		return nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(expected)),
+		B:        difflib.SplitLines(actual),
+		FromFile: snapshotFile,
+		ToFile:   "rendered",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("failed comparing snapshot: %w", err)
+	}
+	fmt.Fprint(t.out, diff)
+	return fmt.Errorf("rendered manifests differ from snapshot %s: run with '--update-snapshots' to update it", snapshotFile)
+}
+
+// renderSnapshot renders the chart twice and returns the normalized manifests. Objects are sorted by template,
+// kind, namespace, and name, and values which differ between both renderings are replaced with a placeholder, so
+// neither the order of objects nor random values cause differences.
+func (t *Testing) renderSnapshot(chart *Chart, valuesFile string) (string, error) {
+	var renderings [2][]manifestObject
+	for i := range renderings {
+		manifests, err := t.helm.Template(chart.Path(), valuesFile, "")
+		if err != nil {
+			return "", fmt.Errorf("failed rendering chart: %w", err)
+		}
+		if renderings[i], err = parseManifests(manifests); err != nil {
+			return "", err
+		}
+	}
+
+	objects := maskRandomValues(renderings[0], renderings[1])
+	slices.SortStableFunc(objects, func(a, b manifestObject) int {
+		return cmp.Or(
+			cmp.Compare(a.Source, b.Source),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(namespaceOf(a), namespaceOf(b)),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+
+	var b bytes.Buffer
+	for _, object := range objects {
+		fmt.Fprintln(&b, "---")
+		if object.Source != "" {
+			fmt.Fprintf(&b, "%s%s\n", sourceCommentPrefix, object.Source)
+		}
+		encoder := yaml.NewEncoder(&b)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(object.Object); err != nil {
+			return "", fmt.Errorf("failed encoding %s: %w", object, err)
+		}
+		if err := encoder.Close(); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// maskRandomValues returns the objects of the first rendering with all values replaced which differ in the second
+// rendering. Objects are matched by position as Helm renders templates in a stable order, or else by identity.
+func maskRandomValues(first []manifestObject, second []manifestObject) []manifestObject {
+	byIdentity := map[string]manifestObject{}
+	for _, object := range second {
+		byIdentity[objectIdentity(object)] = object
+	}
+
+	result := make([]manifestObject, len(first))
+	for i, object := range first {
+		result[i] = object
+		other, ok := byIdentity[objectIdentity(object)]
+		if len(first) == len(second) && second[i].Source == object.Source && second[i].Kind == object.Kind {
+			other, ok = second[i], true
+		}
+		if !ok {
+			continue
+		}
+		result[i].Object = maskDifferences(object.Object, other.Object).(map[string]any)
+		metadata, _ := result[i].Object["metadata"].(map[string]any)
+		result[i].Name, _ = metadata["name"].(string)
+	}
+	return result
+}
+
+// maskDifferences returns a with all values replaced which differ in b.
+func maskDifferences(a any, b any) any {
+	switch aValue := a.(type) {
+	case map[string]any:
+		if bValue, ok := b.(map[string]any); ok {
+			result := make(map[string]any, len(aValue))
+			for key, value := range aValue {
+				if other, ok := bValue[key]; ok {
+					value = maskDifferences(value, other)
+				}
+				result[key] = value
+			}
+			return result
+		}
+	case []any:
+		if bValue, ok := b.([]any); ok && len(aValue) == len(bValue) {
+			result := make([]any, len(aValue))
+			for i := range aValue {
+				result[i] = maskDifferences(aValue[i], bValue[i])
+			}
+			return result
+		}
+	}
+	if reflect.DeepEqual(a, b) {
+		return a
+	}
+	return randomValue
+}
+
+func objectIdentity(object manifestObject) string {
+	return fmt.Sprintf("%s|%s|%s", object.Source, object, namespaceOf(object))
+}
+
+func namespaceOf(object manifestObject) string {
+	metadata, _ := object.Object["metadata"].(map[string]any)
+	namespace, _ := metadata["namespace"].(string)
+	return namespace
+}
@@ -0,0 +1,127 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helm/chart-testing/v3/pkg/config"
+)
+
+func TestCheckSnapshot(t *testing.T) {
+	// Every rendering generates another password, and the order of the objects depends on the values file
+	renderings := 0
+	replicas := 1
+	template := func(_ string, valuesFile string, _ string) (string, error) {
+		renderings++
+		service := "---\n# Source: foo/templates/service.yaml\napiVersion: v1\nkind: Service\nmetadata:\n  name: foo\n"
+		deployment := fmt.Sprintf("---\n# Source: foo/templates/deployment.yaml\napiVersion: apps/v1\nkind: Deployment\n"+
+			"metadata:\n  name: foo\nspec:\n  replicas: %d\n", replicas)
+		secret := fmt.Sprintf("---\n# Source: foo/templates/secret.yaml\napiVersion: v1\nkind: Secret\n"+
+			"metadata:\n  name: foo\nstringData:\n  password: secret-%d\n  username: admin\n", renderings)
+		if valuesFile != "" {
+			return secret + service + deployment, nil
+		}
+		return deployment + secret + service, nil
+	}
+
+	var b strings.Builder
+	ct := newTestingMock(config.Configuration{UpdateSnapshots: true})
+	ct.out = &b
+	ct.helm = &fakeHelm{template: template}
+	chart := &Chart{path: t.TempDir()}
+	snapshotFile := filepath.Join(chart.SnapshotsDir(), "default.yaml")
+
+	require.NoError(t, ct.CheckSnapshot(chart, ""))
+	assert.Contains(t, b.String(), "Updated snapshot "+snapshotFile+"\n")
+	snapshot, err := os.ReadFile(snapshotFile)
+	require.NoError(t, err)
+	assert.Equal(t, `---
+# Source: foo/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 1
+---
+# Source: foo/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: foo
+stringData:
+  password: <random>
+  username: admin
+---
+# Source: foo/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`, string(snapshot))
+
+	ct.config.UpdateSnapshots = false
+	assert.True(t, chart.HasSnapshots())
+	require.NoError(t, os.Rename(snapshotFile, filepath.Join(chart.SnapshotsDir(), "test-values.yaml")))
+	assert.NoError(t, ct.CheckSnapshot(chart, filepath.Join("ci", "test-values.yaml")))
+
+	assert.EqualError(t, ct.CheckSnapshot(chart, ""), "snapshot "+snapshotFile+
+		" not found: run with '--update-snapshots' to create it")
+
+	replicas = 2
+	b.Reset()
+	err = ct.CheckSnapshot(chart, filepath.Join("ci", "test-values.yaml"))
+	assert.EqualError(t, err, "rendered manifests differ from snapshot "+filepath.Join(chart.SnapshotsDir(), "test-values.yaml")+
+		": run with '--update-snapshots' to update it")
+	assert.Contains(t, b.String(), "-  replicas: 1\n+  replicas: 2\n")
+}
+
+func TestMaskDifferences(t *testing.T) {
+	a := map[string]any{"same": "x", "random": "a", "list": []any{1, 2}, "longer": []any{1}, "nested": map[string]any{"v": 1}}
+	b := map[string]any{"same": "x", "random": "b", "list": []any{1, 3}, "longer": []any{1, 2}, "nested": map[string]any{"v": 1}}
+	assert.Equal(t, map[string]any{
+		"same":   "x",
+		"random": randomValue,
+		"list":   []any{1, randomValue},
+		"longer": randomValue,
+		"nested": map[string]any{"v": 1},
+	}, maskDifferences(a, b))
+}
+
+func TestLintChartStopsCheckingSnapshotsAtFirstFailure(t *testing.T) {
+	chart, err := NewChart("testdata/values_schema")
+	require.NoError(t, err)
+
+	ct := newTestingMock(config.Configuration{UpdateSnapshots: true})
+	var b strings.Builder
+	ct.out = &b
+	ct.helm = &fakeHelm{template: func(string, string, string) (string, error) {
+		return "", fmt.Errorf("rendering failed")
+	}}
+
+	result := ct.LintChart(chart)
+	assert.ErrorContains(t, result.Error, "rendering failed")
+	require.Len(t, result.Steps, 1)
+	assert.Equal(t, StepSnapshot, result.Steps[0].Name)
+	assert.NoDirExists(t, chart.SnapshotsDir())
+}
@@ -0,0 +1,183 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+const valuesSchemaFileName = "values.schema.json"
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// HasValuesSchema returns whether the chart ships a 'values.schema.json'.
+func (c *Chart) HasValuesSchema() bool {
+	_, err := os.Stat(filepath.Join(c.path, valuesSchemaFileName))
+	return err == nil
+}
+
+// ValidateValuesSchema validates the chart's default values against its 'values.schema.json'. If valuesFile is not
+// empty, it is merged over the defaults the way Helm merges values files first. Every violation is printed with the
+// values file and the JSON pointer of the offending value.
+func (t *Testing) ValidateValuesSchema(chart *Chart, valuesFile string) error {
+	schemaFile := filepath.Join(chart.Path(), valuesSchemaFileName)
+	valuesYaml := filepath.Join(chart.Path(), "values.yaml")
+
+	values, err := readValuesFile(valuesYaml)
+	if err != nil {
+		return err
+	}
+	validatedFile := valuesYaml
+	if valuesFile != "" {
+		overrides, err := readValuesFile(valuesFile)
+		if err != nil {
+			return err
+		}
+		values = mergeValues(values, overrides)
+		validatedFile = valuesFile
+	}
+
+	fmt.Fprintf(t.out, "Validating %s against %s...\n", validatedFile, schemaFile)
+	violations, err := validateValues(schemaFile, values)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	for _, violation := range violations {
+		fmt.Fprintf(t.out, "%s: %s\n", validatedFile, violation)
+	}
+	return fmt.Errorf("%s does not match %s: found %d violation(s)", validatedFile, schemaFile, len(violations))
+}
+
+// validateValues validates values against the JSON schema in schemaFile and returns all violations, each prefixed
+// with the JSON pointer of the offending value.
+func validateValues(schemaFile string, values map[string]any) ([]string, error) {
+	schemaPath, err := filepath.Abs(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	schema, err := compiler.Compile(schemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed compiling values schema: %w", err)
+	}
+
+	// Convert the values to the types produced by JSON decoding, which the validator expects
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed converting values to JSON: %w", err)
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed converting values to JSON: %w", err)
+	}
+
+	return validateInstance(schema, instance)
+}
+
+// validateInstance validates a JSON decoded instance against schema and returns all violations, each prefixed with
+// the JSON pointer of the offending value.
+func validateInstance(schema *jsonschema.Schema, instance any) ([]string, error) {
+	err := schema.Validate(instance)
+	var validationErr *jsonschema.ValidationError
+	if err == nil {
+		return nil, nil
+	} else if !errors.As(err, &validationErr) {
+		return nil, fmt.Errorf("failed validating: %w", err)
+	}
+
+	printer := message.NewPrinter(language.English)
+	var violations []string
+	var collect func(e *jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			violations = append(violations, fmt.Sprintf("%s: %s", jsonPointer(e.InstanceLocation),
+				e.ErrorKind.LocalizedString(printer)))
+		}
+		for _, cause := range e.Causes {
+			collect(cause)
+		}
+	}
+	collect(validationErr)
+	slices.Sort(violations)
+	return slices.Compact(violations), nil
+}
+
+// readValuesFile reads a values file. A missing file yields empty values, just like in Helm.
+func readValuesFile(valuesFile string) (map[string]any, error) {
+	data, err := os.ReadFile(valuesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]any{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed reading values file: %w", err)
+	}
+
+	values := map[string]any{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed parsing values file %s: %w", valuesFile, err)
+	}
+	return values, nil
+}
+
+// mergeValues merges overrides over defaults the way Helm coalesces user supplied values with a chart's defaults:
+// maps are merged recursively, other values replace the defaults, and null removes a default.
+func mergeValues(defaults map[string]any, overrides map[string]any) map[string]any {
+	result := make(map[string]any, len(defaults))
+	for key, value := range defaults {
+		result[key] = value
+	}
+
+	for key, value := range overrides {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		defaultMap, isDefaultMap := result[key].(map[string]any)
+		overrideMap, isOverrideMap := value.(map[string]any)
+		if isDefaultMap && isOverrideMap {
+			result[key] = mergeValues(defaultMap, overrideMap)
+		} else {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func jsonPointer(location []string) string {
+	if len(location) == 0 {
+		return "/"
+	}
+	var pointer string
+	for _, token := range location {
+		pointer += "/" + jsonPointerEscaper.Replace(token)
+	}
+	return pointer
+}
@@ -0,0 +1,136 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/helm/chart-testing/v3/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateValuesSchema(t *testing.T) {
+	chart, err := NewChart("testdata/values_schema")
+	require.NoError(t, err)
+	assert.True(t, chart.HasValuesSchema())
+
+	var testDataSet = []struct {
+		name           string
+		valuesFile     string
+		expectedOutput []string
+	}{
+		{"defaults", "", []string{}},
+		{"valid values file", "testdata/values_schema/ci/valid-values.yaml", []string{}},
+		{
+			"invalid values file",
+			"testdata/values_schema/ci/invalid-values.yaml",
+			[]string{
+				"testdata/values_schema/ci/invalid-values.yaml: /annotations/example.com~1revision: got number, want string",
+				"testdata/values_schema/ci/invalid-values.yaml: /image/tag: got number, want string",
+				"testdata/values_schema/ci/invalid-values.yaml: /image: missing property 'repository'",
+				"testdata/values_schema/ci/invalid-values.yaml: /replicas: minimum: got 0, want 1",
+			},
+		},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			var b strings.Builder
+			ct := newTestingMock(config.Configuration{})
+			ct.out = &b
+
+			err := ct.ValidateValuesSchema(chart, testData.valuesFile)
+
+			lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+			assert.Equal(t, testData.expectedOutput, lines[1:])
+			if len(testData.expectedOutput) == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "found 4 violation(s)")
+			}
+		})
+	}
+}
+
+func TestLintChartValidatesValuesSchema(t *testing.T) {
+	chart, err := NewChart("testdata/values_schema")
+	require.NoError(t, err)
+
+	var testDataSet = []struct {
+		name          string
+		keepGoing     bool
+		expectedSteps []string
+	}{
+		{
+			"stops at first failure",
+			false,
+			[]string{
+				"values-schema ",
+				"values-schema testdata/values_schema/ci/invalid-values.yaml",
+			},
+		},
+		{
+			"keep going",
+			true,
+			[]string{
+				"values-schema ",
+				"values-schema testdata/values_schema/ci/invalid-values.yaml",
+				"values-schema testdata/values_schema/ci/valid-values.yaml",
+			},
+		},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			ct := newTestingMock(config.Configuration{ValidateValuesSchema: true, KeepGoing: testData.keepGoing})
+			var b strings.Builder
+			ct.out = &b
+
+			result := ct.LintChart(chart)
+			assert.ErrorContains(t, result.Error, "invalid-values.yaml")
+
+			var steps []string
+			for _, step := range result.Steps {
+				if step.Name != StepValuesSchema {
+					continue
+				}
+				steps = append(steps, step.Name+" "+step.ValuesFile)
+				assert.Equal(t, strings.HasSuffix(step.ValuesFile, "invalid-values.yaml"), step.Error != nil)
+			}
+			assert.Equal(t, testData.expectedSteps, steps)
+		})
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	defaults := map[string]any{
+		"image":    map[string]any{"repository": "nginx", "tag": "1.27"},
+		"replicas": 1,
+		"debug":    map[string]any{"enabled": true},
+	}
+	overrides := map[string]any{
+		"image":    map[string]any{"tag": "1.28"},
+		"replicas": nil,
+		"debug":    false,
+	}
+
+	assert.Equal(t, map[string]any{
+		"image": map[string]any{"repository": "nginx", "tag": "1.28"},
+		"debug": false,
+	}, mergeValues(defaults, overrides))
+	assert.Equal(t, map[string]any{"repository": "nginx", "tag": "1.27"}, defaults["image"], "defaults must not be modified")
+}
@@ -20,9 +20,11 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/mitchellh/go-homedir"
 
 	"github.com/helm/chart-testing/v3/pkg/util"
@@ -31,6 +33,9 @@ import (
 	"github.com/spf13/viper"
 )
 
+// podSecurityLevels are the levels of the Pod Security Standards.
+var podSecurityLevels = []string{"privileged", "baseline", "restricted"}
+
 var (
 	homeDir, _            = homedir.Dir()
 	configSearchLocations = []string{
@@ -40,6 +45,41 @@ var (
 		"/usr/local/etc/ct",
 		"/etc/ct",
 	}
+
+	// globalOnlySettings are settings which apply to a run as a whole and can therefore
+	// not be overridden in a chart's configuration.
+	globalOnlySettings = []string{
+		"remote",
+		"target-branch",
+		"since",
+		"build-id",
+		"all",
+		"charts",
+		"chart-repos",
+		"chart-dirs",
+		"excluded-charts",
+		"helm-repo-extra-args",
+		"debug",
+		"upgrade",
+		"exclude-deprecated",
+		"github-groups",
+		"use-helmignore",
+		"parallelism",
+		"include-dependents",
+		"junit-report",
+		"output",
+		"results-file",
+		"kubernetes-client",
+		"helm-client",
+		"artifacts-dir",
+		"kube-contexts",
+		"schema-dir",
+		"total-timeout",
+		"cleanup-grace-period",
+		"quarantine",
+		"older-than",
+		"namespace-profiles",
+	}
 )
 
 type Configuration struct {
@@ -48,10 +88,17 @@ type Configuration struct {
 	Since                   string        `mapstructure:"since"`
 	BuildID                 string        `mapstructure:"build-id"`
 	LintConf                string        `mapstructure:"lint-conf"`
+	YamlLinter              string        `mapstructure:"yaml-linter"`
 	ChartYamlSchema         string        `mapstructure:"chart-yaml-schema"`
 	ValidateMaintainers     bool          `mapstructure:"validate-maintainers"`
 	ValidateChartSchema     bool          `mapstructure:"validate-chart-schema"`
 	ValidateYaml            bool          `mapstructure:"validate-yaml"`
+	ValidateValuesSchema    bool          `mapstructure:"validate-values-schema"`
+	KeepGoing               bool          `mapstructure:"keep-going"`
+	KubeVersions            []string      `mapstructure:"kube-versions"`
+	SchemaDir               string        `mapstructure:"schema-dir"`
+	IgnoreMissingSchemas    bool          `mapstructure:"ignore-missing-schemas"`
+	UpdateSnapshots         bool          `mapstructure:"update-snapshots"`
 	SkipHelmDependencies    bool          `mapstructure:"skip-helm-dependencies"`
 	AdditionalCommands      []string      `mapstructure:"additional-commands"`
 	CheckVersionIncrement   bool          `mapstructure:"check-version-increment"`
@@ -67,6 +114,13 @@ type Configuration struct {
 	HelmDependencyExtraArgs []string      `mapstructure:"helm-dependency-extra-args"`
 	Debug                   bool          `mapstructure:"debug"`
 	Upgrade                 bool          `mapstructure:"upgrade"`
+	UpgradeFrom             []string      `mapstructure:"upgrade-from"`
+	UpgradeVersions         int           `mapstructure:"upgrade-versions"`
+	UpgradeChain            bool          `mapstructure:"upgrade-chain"`
+	Rollback                bool          `mapstructure:"rollback"`
+	Retries                 int           `mapstructure:"retries"`
+	RetryBackoff            time.Duration `mapstructure:"retry-backoff"`
+	Quarantine              []string      `mapstructure:"quarantine"`
 	SkipMissingValues       bool          `mapstructure:"skip-missing-values"`
 	SkipCleanUp             bool          `mapstructure:"skip-clean-up"`
 	Namespace               string        `mapstructure:"namespace"`
@@ -74,16 +128,67 @@ type Configuration struct {
 	ReleaseLabel            string        `mapstructure:"release-label"`
 	ExcludeDeprecated       bool          `mapstructure:"exclude-deprecated"`
 	KubectlTimeout          time.Duration `mapstructure:"kubectl-timeout"`
-	PrintLogs               bool          `mapstructure:"print-logs"`
-	GithubGroups            bool          `mapstructure:"github-groups"`
-	UseHelmignore           bool          `mapstructure:"use-helmignore"`
+	ReadinessTimeout        time.Duration `mapstructure:"readiness-timeout"`
+	ChartTimeout            time.Duration `mapstructure:"chart-timeout"`
+	TotalTimeout            time.Duration `mapstructure:"total-timeout"`
+	CleanupGracePeriod      time.Duration `mapstructure:"cleanup-grace-period"`
+	OlderThan               time.Duration `mapstructure:"older-than"`
+	NamespaceProfile        string        `mapstructure:"namespace-profile"`
+	// NamespaceProfiles is decoded by decodeNamespaceProfiles
+	NamespaceProfiles map[string]NamespaceProfile `mapstructure:"-"`
+	KubernetesClient  string                      `mapstructure:"kubernetes-client"`
+	KubeContexts      []string                    `mapstructure:"kube-contexts"`
+	HelmClient        string                      `mapstructure:"helm-client"`
+	PrintLogs         bool                        `mapstructure:"print-logs"`
+	GithubGroups      bool                        `mapstructure:"github-groups"`
+	UseHelmignore     bool                        `mapstructure:"use-helmignore"`
+	Parallelism       int                         `mapstructure:"parallelism"`
+	IncludeDependents bool                        `mapstructure:"include-dependents"`
+	JUnitReport       string                      `mapstructure:"junit-report"`
+	Output            string                      `mapstructure:"output"`
+	ResultsFile       string                      `mapstructure:"results-file"`
+	ArtifactsDir      string                      `mapstructure:"artifacts-dir"`
+}
+
+// NamespaceProfile hardens the namespaces ct creates for installing charts, so that installing a chart fails unless it
+// complies with the Pod Security Standard, quota, and network policy of the profile.
+type NamespaceProfile struct {
+	// PodSecurity is the Pod Security Standard enforced in the namespace, i.e. 'privileged', 'baseline', or 'restricted'
+	PodSecurity string `mapstructure:"pod-security"`
+	// ResourceQuota limits the total of resources, e.g. 'requests.cpu' or 'pods', used in the namespace
+	ResourceQuota map[string]string `mapstructure:"resource-quota"`
+	LimitRange    LimitRange        `mapstructure:"limit-range"`
+	// DefaultDenyNetworkPolicy denies all ingress and egress traffic of the pods in the namespace except DNS lookups
+	// in kube-system. Charts whose pods or tests talk to each other need to ship network policies allowing it
+	DefaultDenyNetworkPolicy bool `mapstructure:"default-deny-network-policy"`
+}
+
+// LimitRange holds the default, minimum, and maximum resources of each container in a namespace.
+type LimitRange struct {
+	Default        map[string]string `mapstructure:"default"`
+	DefaultRequest map[string]string `mapstructure:"default-request"`
+	Min            map[string]string `mapstructure:"min"`
+	Max            map[string]string `mapstructure:"max"`
+}
+
+// IsEmpty returns whether the limit range does not set any resources.
+func (l LimitRange) IsEmpty() bool {
+	return len(l.Default) == 0 && len(l.DefaultRequest) == 0 && len(l.Min) == 0 && len(l.Max) == 0
 }
 
 func LoadConfiguration(cfgFile string, cmd *cobra.Command, printConfig bool) (*Configuration, error) {
 	v := viper.New()
 
 	v.SetDefault("kubectl-timeout", 30*time.Second)
+	v.SetDefault("readiness-timeout", 10*time.Minute)
 	v.SetDefault("print-logs", bool(true))
+	v.SetDefault("parallelism", 1)
+	v.SetDefault("upgrade-versions", 1)
+	v.SetDefault("retry-backoff", 10*time.Second)
+	v.SetDefault("output", "text")
+	v.SetDefault("yaml-linter", "yamllint")
+	v.SetDefault("kubernetes-client", "kubectl")
+	v.SetDefault("helm-client", "helm")
 
 	cmd.Flags().VisitAll(func(flag *flag.Flag) {
 		flagName := flag.Name
@@ -125,18 +230,26 @@ func LoadConfiguration(cfgFile string, cmd *cobra.Command, printConfig bool) (*C
 
 	isLint := strings.Contains(cmd.Use, "lint")
 	isInstall := strings.Contains(cmd.Use, "install")
+	isValidate := cmd.Use == "validate"
 
 	cfg := &Configuration{}
 	if err := v.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf("failed unmarshaling configuration: %w", err)
 	}
+	if err := decodeNamespaceProfiles(v, cfg); err != nil {
+		return nil, err
+	}
 
 	if cfg.ProcessAllCharts && len(cfg.Charts) > 0 {
 		return nil, errors.New("specifying both, '--all' and '--charts', is not allowed")
 	}
 
-	if cfg.Namespace != "" && cfg.ReleaseLabel == "" {
-		return nil, errors.New("specifying '--namespace' without '--release-label' is not allowed")
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	if isValidate && cfg.SchemaDir == "" {
+		return nil, errors.New("'--schema-dir' is required for validating manifests")
 	}
 
 	// Disable upgrade (this does some expensive dependency building on previous revisions)
@@ -178,6 +291,117 @@ func LoadConfiguration(cfgFile string, cmd *cobra.Command, printConfig bool) (*C
 	return cfg, nil
 }
 
+// LoadChartConfiguration merges the optional configuration file 'ct.yaml' (or any other supported format) found in
+// chartDir or in chartDir/.ct over cfg and returns the result. Settings which only apply globally are rejected.
+// If no configuration file is found, cfg is returned unchanged.
+func LoadChartConfiguration(cfg Configuration, chartDir string) (*Configuration, error) {
+	v := viper.New()
+	v.SetConfigName("ct")
+	v.AddConfigPath(chartDir)
+	v.AddConfigPath(filepath.Join(chartDir, ".ct"))
+
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) {
+			return &cfg, nil
+		}
+		return nil, fmt.Errorf("failed loading chart config file: %w", err)
+	}
+
+	for _, key := range v.AllKeys() {
+		// Nested settings, e.g. those of namespace profiles, are checked by the top-level setting they belong to
+		key, _, _ = strings.Cut(key, ".")
+		if slices.Contains(globalOnlySettings, key) {
+			return nil, fmt.Errorf("setting %q in %q is not allowed: it can only be configured globally", key, v.ConfigFileUsed())
+		}
+	}
+
+	// Lists from the chart's configuration replace those of the global configuration instead of being merged
+	zeroFields := func(c *mapstructure.DecoderConfig) {
+		c.ZeroFields = true
+	}
+	if err := v.Unmarshal(&cfg, zeroFields); err != nil {
+		return nil, fmt.Errorf("failed unmarshaling chart configuration %q: %w", v.ConfigFileUsed(), err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid chart configuration %q: %w", v.ConfigFileUsed(), err)
+	}
+
+	fmt.Fprintln(os.Stderr, "Using chart config file:", v.ConfigFileUsed())
+	return &cfg, nil
+}
+
+// decodeNamespaceProfiles decodes 'namespace-profiles' from the raw configuration. Resource names such as
+// 'requests.cpu' contain dots, at which viper splits keys when the configuration is unmarshaled as a whole.
+func decodeNamespaceProfiles(v *viper.Viper, cfg *Configuration) error {
+	raw := v.Get("namespace-profiles")
+	if raw == nil {
+		return nil
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           &cfg.NamespaceProfiles,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return fmt.Errorf("failed unmarshaling 'namespace-profiles': %w", err)
+	}
+	return nil
+}
+
+// validate checks the settings which can be configured both globally and per chart, so that the configuration of a
+// chart is checked the same way as the global configuration.
+func (c *Configuration) validate() error {
+	if c.Parallelism < 1 {
+		return errors.New("'parallelism' must be at least 1")
+	}
+
+	if c.UpgradeVersions < 1 {
+		return errors.New("'upgrade-versions' must be at least 1")
+	}
+
+	if c.Retries < 0 {
+		return errors.New("'retries' must not be negative")
+	}
+
+	if c.Output != "text" && c.Output != "json" {
+		return fmt.Errorf("invalid output format %q: must be 'text' or 'json'", c.Output)
+	}
+
+	if c.YamlLinter != "yamllint" && c.YamlLinter != "native" {
+		return fmt.Errorf("invalid YAML linter %q: must be 'yamllint' or 'native'", c.YamlLinter)
+	}
+
+	if c.KubernetesClient != "kubectl" && c.KubernetesClient != "client-go" {
+		return fmt.Errorf("invalid Kubernetes client %q: must be 'kubectl' or 'client-go'", c.KubernetesClient)
+	}
+
+	if c.HelmClient != "helm" && c.HelmClient != "sdk" {
+		return fmt.Errorf("invalid Helm client %q: must be 'helm' or 'sdk'", c.HelmClient)
+	}
+
+	if c.Namespace != "" && c.ReleaseLabel == "" {
+		return errors.New("specifying 'namespace' without 'release-label' is not allowed")
+	}
+
+	for name, profile := range c.NamespaceProfiles {
+		if profile.PodSecurity != "" && !slices.Contains(podSecurityLevels, profile.PodSecurity) {
+			return fmt.Errorf("invalid Pod Security level %q in namespace profile %q: must be one of %s",
+				profile.PodSecurity, name, strings.Join(podSecurityLevels, ", "))
+		}
+	}
+
+	if c.NamespaceProfile != "" {
+		if _, ok := c.NamespaceProfiles[c.NamespaceProfile]; !ok {
+			return fmt.Errorf("namespace profile %q not found in 'namespace-profiles'", c.NamespaceProfile)
+		}
+	}
+	return nil
+}
+
 func printCfg(cfg *Configuration) {
 	if !cfg.GithubGroups {
 		util.PrintDelimiterLineToWriter(os.Stderr, "-")
@@ -195,8 +419,10 @@ func printCfg(cfg *Configuration) {
 		switch e.Field(i).Kind() {
 		case reflect.Bool:
 			pattern = "%s: %t\n"
+		case reflect.Int:
+			pattern = "%s: %d\n"
 		default:
-			pattern = "%s: %s\n"
+			pattern = "%s: %v\n"
 		}
 		fmt.Fprintf(os.Stderr, pattern, typeOfCfg.Field(i).Name, e.Field(i).Interface())
 	}
@@ -42,10 +42,21 @@ func loadAndAssertConfigFromFile(t *testing.T, configFile string) {
 	require.Equal(t, "main", cfg.TargetBranch)
 	require.Equal(t, "pr-42", cfg.BuildID)
 	require.Equal(t, "my-lint-conf.yaml", cfg.LintConf)
+	require.Equal(t, "native", cfg.YamlLinter)
 	require.Equal(t, "my-chart-yaml-schema.yaml", cfg.ChartYamlSchema)
 	require.True(t, cfg.ValidateMaintainers)
 	require.True(t, cfg.ValidateChartSchema)
 	require.True(t, cfg.ValidateYaml)
+	require.True(t, cfg.ValidateValuesSchema)
+	require.True(t, cfg.KeepGoing)
+	require.Equal(t, []string{"1.25", "1.29"}, cfg.KubeVersions)
+	require.Equal(t, "schemas", cfg.SchemaDir)
+	require.True(t, cfg.IgnoreMissingSchemas)
+	require.True(t, cfg.UpdateSnapshots)
+	require.Equal(t, "client-go", cfg.KubernetesClient)
+	require.Equal(t, "sdk", cfg.HelmClient)
+	require.Equal(t, "artifacts", cfg.ArtifactsDir)
+	require.Equal(t, []string{"kind-v1.30", "kind-v1.31"}, cfg.KubeContexts)
 	require.True(t, cfg.CheckVersionIncrement)
 	require.False(t, cfg.ProcessAllCharts)
 	require.Equal(t, []string{"incubator=https://incubator"}, cfg.ChartRepos)
@@ -55,13 +66,94 @@ func loadAndAssertConfigFromFile(t *testing.T, configFile string) {
 	require.Equal(t, "--timeout 300s", cfg.HelmExtraArgs)
 	require.Equal(t, "--quiet", cfg.HelmLintExtraArgs)
 	require.True(t, cfg.Upgrade)
+	require.Equal(t, []string{"merge-base", "oci://ghcr.io/org/charts"}, cfg.UpgradeFrom)
+	require.Equal(t, 3, cfg.UpgradeVersions)
+	require.True(t, cfg.UpgradeChain)
+	require.True(t, cfg.Rollback)
+	require.Equal(t, 2, cfg.Retries)
+	require.Equal(t, 30*time.Second, cfg.RetryBackoff)
+	require.Equal(t, []string{"charts/flaky"}, cfg.Quarantine)
 	require.True(t, cfg.SkipMissingValues)
 	require.Equal(t, "default", cfg.Namespace)
 	require.Equal(t, "release", cfg.ReleaseLabel)
 	require.True(t, cfg.ExcludeDeprecated)
 	require.Equal(t, 120*time.Second, cfg.KubectlTimeout)
+	require.Equal(t, 12*time.Minute, cfg.ReadinessTimeout)
+	require.Equal(t, 15*time.Minute, cfg.ChartTimeout)
+	require.Equal(t, time.Hour, cfg.TotalTimeout)
+	require.Equal(t, 3*time.Hour, cfg.OlderThan)
+	require.Equal(t, "restricted", cfg.NamespaceProfile)
+	require.Equal(t, map[string]NamespaceProfile{
+		"restricted": {
+			PodSecurity:   "restricted",
+			ResourceQuota: map[string]string{"requests.cpu": "2", "limits.memory": "4Gi"},
+			LimitRange: LimitRange{
+				Default: map[string]string{"cpu": "500m"},
+				Max:     map[string]string{"memory": "1Gi"},
+			},
+			DefaultDenyNetworkPolicy: true,
+		},
+	}, cfg.NamespaceProfiles)
 	require.True(t, cfg.SkipCleanUp)
 	require.True(t, cfg.UseHelmignore)
+	require.Equal(t, 4, cfg.Parallelism)
+	require.Equal(t, "ct-report.xml", cfg.JUnitReport)
+	require.Equal(t, "json", cfg.Output)
+	require.Equal(t, "ct-results.json", cfg.ResultsFile)
+}
+
+func TestLoadChartConfiguration(t *testing.T) {
+	cfg := Configuration{
+		TargetBranch:        "main",
+		HelmExtraArgs:       "--timeout 300s",
+		ValidateMaintainers: true,
+		ReleaseLabel:        "app.kubernetes.io/instance",
+		AdditionalCommands:  []string{"echo global", "echo global too"},
+		KubectlTimeout:      30 * time.Second,
+		Parallelism:         1,
+		UpgradeVersions:     1,
+		Output:              "text",
+		YamlLinter:          "yamllint",
+		KubernetesClient:    "kubectl",
+		HelmClient:          "helm",
+		NamespaceProfile:    "restricted",
+		NamespaceProfiles: map[string]NamespaceProfile{
+			"restricted": {PodSecurity: "restricted"},
+			"baseline":   {PodSecurity: "baseline"},
+		},
+	}
+
+	chartCfg, err := LoadChartConfiguration(cfg, filepath.Join("testdata", "chart-overrides"))
+	require.NoError(t, err)
+	require.Equal(t, "main", chartCfg.TargetBranch)
+	require.Equal(t, "--timeout 900s", chartCfg.HelmExtraArgs)
+	require.False(t, chartCfg.ValidateMaintainers)
+	require.Equal(t, "app", chartCfg.ReleaseLabel)
+	require.Equal(t, []string{"echo chart"}, chartCfg.AdditionalCommands)
+	require.Equal(t, 30*time.Second, chartCfg.KubectlTimeout)
+	require.Equal(t, 20*time.Minute, chartCfg.ChartTimeout)
+	require.Equal(t, 30*time.Minute, chartCfg.ReadinessTimeout)
+	require.Equal(t, 3, chartCfg.Retries)
+	require.Equal(t, "baseline", chartCfg.NamespaceProfile)
+	require.Equal(t, cfg.NamespaceProfiles, chartCfg.NamespaceProfiles)
+
+	chartCfg, err = LoadChartConfiguration(cfg, filepath.Join("testdata", "default"))
+	require.NoError(t, err)
+	require.Equal(t, cfg, *chartCfg)
+
+	_, err = LoadChartConfiguration(cfg, filepath.Join("testdata", "chart-global-only"))
+	require.ErrorContains(t, err, `setting "target-branch"`)
+
+	// Namespace profiles can only be configured globally, which applies to their nested settings as well
+	_, err = LoadChartConfiguration(cfg, filepath.Join("testdata", "chart-namespace-profiles"))
+	require.ErrorContains(t, err, `setting "namespace-profiles"`)
+
+	_, err = LoadChartConfiguration(cfg, filepath.Join("testdata", "chart-invalid"))
+	require.ErrorContains(t, err, "'retries' must not be negative")
+
+	cfg.NamespaceProfiles = nil
+	_, err = LoadChartConfiguration(cfg, filepath.Join("testdata", "chart-overrides"))
+	require.ErrorContains(t, err, `namespace profile "baseline" not found`)
 }
 
 func Test_findConfigFile(t *testing.T) {
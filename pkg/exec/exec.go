@@ -16,23 +16,60 @@ package exec
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/helm/chart-testing/v3/pkg/util"
 )
 
+// waitDelay is the time to wait for the output of a canceled process to be closed after it has been killed.
+const waitDelay = 10 * time.Second
+
 type ProcessExecutor struct {
 	debug bool
+	out   io.Writer
+	ctx   context.Context
 }
 
 func NewProcessExecutor(debug bool) ProcessExecutor {
 	return ProcessExecutor{
 		debug: debug,
+		out:   os.Stdout,
+	}
+}
+
+// WithOutput returns a copy of the ProcessExecutor that writes process output to w.
+func (p ProcessExecutor) WithOutput(w io.Writer) ProcessExecutor {
+	p.out = w
+	return p
+}
+
+// WithContext returns a copy of the ProcessExecutor whose processes are killed, along with all processes they
+// started, once ctx is done.
+func (p ProcessExecutor) WithContext(ctx context.Context) ProcessExecutor {
+	p.ctx = ctx
+	return p
+}
+
+// Context returns the context processes are bound to.
+func (p ProcessExecutor) Context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+// Output returns the writer process output is written to.
+func (p ProcessExecutor) Output() io.Writer {
+	if p.out == nil {
+		return os.Stdout
 	}
+	return p.out
 }
 
 func (p ProcessExecutor) RunProcessAndCaptureOutput(executable string, execArgs ...interface{}) (string, error) {
@@ -53,7 +90,7 @@ func (p ProcessExecutor) RunProcessInDirAndCaptureOutput(workingDirectory string
 	bytes, err := cmd.CombinedOutput()
 
 	if err != nil {
-		return "", fmt.Errorf("failed running process: %w", err)
+		return "", fmt.Errorf("failed running process: %w", p.processError(err))
 	}
 	return strings.TrimSpace(string(bytes)), nil
 }
@@ -68,7 +105,7 @@ func (p ProcessExecutor) RunProcessInDirAndCaptureStdout(workingDirectory string
 	bytes, err := cmd.Output()
 
 	if err != nil {
-		return "", fmt.Errorf("failed running process: %w", err)
+		return "", fmt.Errorf("failed running process: %w", p.processError(err))
 	}
 	return strings.TrimSpace(string(bytes)), nil
 }
@@ -89,23 +126,28 @@ func (p ProcessExecutor) RunProcess(executable string, execArgs ...interface{})
 		return fmt.Errorf("failed getting StderrPipe for command: %w", err)
 	}
 
+	out := p.Output()
 	scanner := bufio.NewScanner(io.MultiReader(outReader, errReader))
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		defer outReader.Close()
 		defer errReader.Close()
 		for scanner.Scan() {
-			fmt.Println(scanner.Text())
+			fmt.Fprintln(out, scanner.Text())
 		}
 	}()
 
 	err = cmd.Start()
 	if err != nil {
-		return fmt.Errorf("failed running process: %w", err)
+		return fmt.Errorf("failed running process: %w", p.processError(err))
 	}
 
+	// All output must have been read before waiting for the process to exit.
+	<-done
 	err = cmd.Wait()
 	if err != nil {
-		return fmt.Errorf("failed waiting for process: %w", err)
+		return fmt.Errorf("failed waiting for process: %w", p.processError(err))
 	}
 
 	return nil
@@ -114,26 +156,40 @@ func (p ProcessExecutor) RunProcess(executable string, execArgs ...interface{})
 func (p ProcessExecutor) CreateProcess(executable string, execArgs ...interface{}) (*exec.Cmd, error) {
 	args, err := util.Flatten(execArgs)
 	if p.debug {
-		fmt.Println(">>>", executable, strings.Join(args, " "))
+		fmt.Fprintln(p.Output(), ">>>", executable, strings.Join(args, " "))
 	}
 	if err != nil {
 		return nil, fmt.Errorf("invalid arguments supplied: %w", err)
 	}
-	cmd := exec.Command(executable, args...)
+	cmd := exec.CommandContext(p.Context(), executable, args...)
+	// Processes run in a process group of their own, so that processes they start are killed as well on cancellation
+	setProcessGroup(cmd)
+	cmd.WaitDelay = waitDelay
 
 	return cmd, nil
 }
 
+// processError returns the cause of the cancellation of the context if a process failed because it was killed on
+// cancellation, or else err.
+func (p ProcessExecutor) processError(err error) error {
+	if cause := context.Cause(p.Context()); cause != nil {
+		return cause
+	}
+	return err
+}
+
 type fn func(port int) error
 
-func (p ProcessExecutor) RunWithProxy(withProxy fn) error {
+// RunWithProxy runs withProxy while 'kubectl proxy' is serving the Kubernetes API on the port passed to withProxy.
+// kubectlArgs are passed to kubectl before the proxy command, e.g. to select the kube context to proxy.
+func (p ProcessExecutor) RunWithProxy(kubectlArgs []string, withProxy fn) error {
 	randomPort, err := util.GetRandomPort()
 	if err != nil {
 		return fmt.Errorf("could not find a free port for running 'kubectl proxy': %w", err)
 	}
 
-	fmt.Printf("Running 'kubectl proxy' on port %d\n", randomPort)
-	cmdProxy, err := p.CreateProcess("kubectl", "proxy", fmt.Sprintf("--port=%d", randomPort))
+	fmt.Fprintf(p.Output(), "Running 'kubectl proxy' on port %d\n", randomPort)
+	cmdProxy, err := p.CreateProcess("kubectl", kubectlArgs, "proxy", fmt.Sprintf("--port=%d", randomPort))
 	if err != nil {
 		return fmt.Errorf("failed creating the 'kubectl proxy' process: %w", err)
 	}
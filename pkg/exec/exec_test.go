@@ -0,0 +1,75 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunProcessWithContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("processes are run with sh")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	p := NewProcessExecutor(false).WithOutput(io.Discard).WithContext(ctx)
+
+	// The child of the shell keeps the output open, so the process only returns once the whole group is killed
+	start := time.Now()
+	err := p.RunProcess("sh", "-c", "sleep 30 & wait")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	_, err = p.RunProcessAndCaptureOutput("sh", "-c", "sleep 30")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRunWithProxyPassesKubectlArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake kubectl is a shell script")
+	}
+
+	// The fake kubectl records its arguments and keeps running like 'kubectl proxy'
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\nexec sleep 30\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	p := NewProcessExecutor(false).WithOutput(io.Discard)
+	var args string
+	err := p.RunWithProxy([]string{"--request-timeout=30s", "--context=kind-b"}, func(port int) error {
+		assert.Eventually(t, func() bool {
+			output, err := os.ReadFile(argsFile)
+			args = strings.TrimSpace(string(output))
+			return err == nil && args != ""
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, "--request-timeout=30s --context=kind-b proxy --port="+strconv.Itoa(port), args)
+		return nil
+	})
+	assert.NoError(t, err)
+}
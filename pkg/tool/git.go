@@ -50,6 +50,10 @@ func (g Git) Show(file string, remote string, branch string) (string, error) {
 	return g.exec.RunProcessAndCaptureOutput("git", "show", fileSpec)
 }
 
+func (g Git) CurrentCommit() (string, error) {
+	return g.exec.RunProcessAndCaptureOutput("git", "rev-parse", "HEAD")
+}
+
 func (g Git) MergeBase(commit1 string, commit2 string) (string, error) {
 	return g.exec.RunProcessAndCaptureOutput("git", "merge-base", commit1, commit2)
 }
@@ -79,3 +83,14 @@ func (g Git) BranchExists(branch string) bool {
 	_, err := g.exec.RunProcessAndCaptureOutput("git", "rev-parse", "--verify", branch)
 	return err == nil
 }
+
+func (g Git) ListTags(pattern string) ([]string, error) {
+	tags, err := g.exec.RunProcessAndCaptureOutput("git", "tag", "--list", pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing tags: %w", err)
+	}
+	if tags == "" {
+		return nil, nil
+	}
+	return strings.Split(tags, "\n"), nil
+}
@@ -26,6 +26,7 @@ type Helm struct {
 	extraArgs     []string
 	lintExtraArgs []string
 	extraSetArgs  []string
+	kubeContext   string
 }
 
 func NewHelm(exec exec.ProcessExecutor, extraArgs, lintExtraArgs, extraSetArgs []string) Helm {
@@ -37,6 +38,12 @@ func NewHelm(exec exec.ProcessExecutor, extraArgs, lintExtraArgs, extraSetArgs [
 	}
 }
 
+// WithKubeContext returns a copy of h which operates on the given context of the kubeconfig.
+func (h Helm) WithKubeContext(kubeContext string) Helm {
+	h.kubeContext = kubeContext
+	return h
+}
+
 func (h Helm) AddRepo(name string, url string, extraArgs []string) error {
 	const ociPrefix string = "oci://"
 
@@ -71,7 +78,7 @@ func (h Helm) InstallWithValues(chart string, valuesFile string, namespace strin
 		values = []string{"--values", valuesFile}
 	}
 
-	return h.exec.RunProcess("helm", "install", release, chart, "--namespace", namespace,
+	return h.exec.RunProcess("helm", "install", release, chart, "--namespace", namespace, h.kubeContextArgs(),
 		"--wait", values, h.extraArgs, h.extraSetArgs)
 }
 
@@ -81,21 +88,68 @@ func (h Helm) UpgradeWithValues(chart string, valuesFile string, namespace strin
 		values = []string{"--values", valuesFile}
 	}
 
-	return h.exec.RunProcess("helm", "upgrade", release, chart, "--namespace", namespace,
+	return h.exec.RunProcess("helm", "upgrade", release, chart, "--namespace", namespace, h.kubeContextArgs(),
 		"--wait", values, h.extraArgs, h.extraSetArgs)
 }
 
+func (h Helm) Rollback(namespace string, release string) error {
+	return h.exec.RunProcess("helm", "rollback", release, "--namespace", namespace, h.kubeContextArgs(), "--wait", h.extraArgs)
+}
+
 func (h Helm) Test(namespace string, release string) error {
-	return h.exec.RunProcess("helm", "test", release, "--namespace", namespace, h.extraArgs)
+	return h.exec.RunProcess("helm", "test", release, "--namespace", namespace, h.kubeContextArgs(), h.extraArgs)
+}
+
+func (h Helm) DeleteRelease(namespace string, release string) error {
+	fmt.Fprintf(h.exec.Output(), "Deleting release %q...\n", release)
+	if err := h.exec.RunProcess("helm", "uninstall", release, "--namespace", namespace, h.kubeContextArgs(), "--wait", h.extraArgs); err != nil {
+		return fmt.Errorf("failed deleting release %q: %w", release, err)
+	}
+	return nil
+}
+
+func (h Helm) ListReleases(namespace string) ([]string, error) {
+	output, err := h.exec.RunProcessAndCaptureStdout("helm", "list", "--namespace", namespace, h.kubeContextArgs(),
+		"--all", "--short")
+	if err != nil {
+		return nil, fmt.Errorf("failed listing releases in namespace %q: %w", namespace, err)
+	}
+	return strings.Fields(output), nil
+}
+
+func (h Helm) Template(chart string, valuesFile string, kubeVersion string) (string, error) {
+	var values []string
+	if valuesFile != "" {
+		values = []string{"--values", valuesFile}
+	}
+	var kubeVersionArgs []string
+	if kubeVersion != "" {
+		kubeVersionArgs = []string{"--kube-version", kubeVersion}
+	}
+
+	return h.exec.RunProcessAndCaptureStdout("helm", "template", chart, values, kubeVersionArgs, h.extraSetArgs)
 }
 
-func (h Helm) DeleteRelease(namespace string, release string) {
-	fmt.Printf("Deleting release %q...\n", release)
-	if err := h.exec.RunProcess("helm", "uninstall", release, "--namespace", namespace, "--wait", h.extraArgs); err != nil {
-		fmt.Println("Error deleting Helm release:", err)
+func (h Helm) Pull(chart string, version string, destDir string) error {
+	var versionArgs []string
+	if version != "" {
+		versionArgs = []string{"--version", version}
 	}
+
+	return h.exec.RunProcess("helm", "pull", chart, versionArgs, "--untar", "--untardir", destDir)
+}
+
+func (h Helm) GetManifest(namespace string, release string) (string, error) {
+	return h.exec.RunProcessAndCaptureStdout("helm", "get", "manifest", release, "--namespace", namespace, h.kubeContextArgs())
 }
 
 func (h Helm) Version() (string, error) {
 	return h.exec.RunProcessAndCaptureStdout("helm", "version", "--template", "{{ .Version }}")
 }
+
+func (h Helm) kubeContextArgs() []string {
+	if h.kubeContext == "" {
+		return nil
+	}
+	return []string{"--kube-context", h.kubeContext}
+}
@@ -0,0 +1,585 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"helm.sh/helm/v3/pkg/action"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const helmModulePath = "helm.sh/helm/v3"
+
+// HelmSDK implements the operations of Helm in-process with the Helm SDK instead of running the helm executable.
+// Extra arguments are parsed like the flags of the Helm CLI, see helmFlags for the supported flags.
+type HelmSDK struct {
+	out           io.Writer
+	settings      *cli.EnvSettings
+	extraArgs     []string
+	lintExtraArgs []string
+	extraSetArgs  []string
+	// actionConfig overrides the creation of the configuration of actions operating on releases in namespace
+	actionConfig func(namespace string) (*action.Configuration, error)
+	ctx          context.Context
+}
+
+func NewHelmSDK(out io.Writer, extraArgs, lintExtraArgs, extraSetArgs []string) HelmSDK {
+	return HelmSDK{
+		out:           out,
+		settings:      cli.New(),
+		extraArgs:     extraArgs,
+		lintExtraArgs: lintExtraArgs,
+		extraSetArgs:  extraSetArgs,
+	}
+}
+
+// WithKubeContext returns a copy of h which operates on the given context of the kubeconfig.
+func (h HelmSDK) WithKubeContext(kubeContext string) HelmSDK {
+	settings := *h.settings
+	settings.KubeContext = kubeContext
+	h.settings = &settings
+	return h
+}
+
+// WithContext returns a copy of h whose installs and upgrades are canceled once ctx is done. Other actions of the Helm
+// SDK cannot be canceled and are only bounded by '--timeout'.
+func (h HelmSDK) WithContext(ctx context.Context) HelmSDK {
+	h.ctx = ctx
+	return h
+}
+
+func (h HelmSDK) context() context.Context {
+	if h.ctx == nil {
+		return context.Background()
+	}
+	return h.ctx
+}
+
+func (h HelmSDK) newActionConfig(namespace string) (*action.Configuration, error) {
+	if h.actionConfig != nil {
+		return h.actionConfig(namespace)
+	}
+
+	settings := *h.settings
+	settings.SetNamespace(namespace)
+	cfg := new(action.Configuration)
+	if err := cfg.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), h.debugf); err != nil {
+		return nil, fmt.Errorf("failed initializing Helm: %w", err)
+	}
+	registryClient, err := h.registryClient()
+	if err != nil {
+		return nil, err
+	}
+	cfg.RegistryClient = registryClient
+	return cfg, nil
+}
+
+// helmFlags holds the values of the Helm CLI flags supported in extra arguments. Flags which don't apply to an
+// operation are ignored.
+type helmFlags struct {
+	timeout     time.Duration
+	waitForJobs bool
+	atomic      bool
+	strict      bool
+	skipRefresh bool
+	values      values.Options
+
+	username              string
+	password              string
+	passCredentials       bool
+	caFile                string
+	certFile              string
+	keyFile               string
+	insecureSkipTLSVerify bool
+	plainHTTP             bool
+}
+
+func parseHelmFlags(args ...[]string) (*helmFlags, error) {
+	var allArgs []string
+	for _, a := range args {
+		allArgs = append(allArgs, a...)
+	}
+
+	f := &helmFlags{}
+	flags := flag.NewFlagSet("helm", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.DurationVar(&f.timeout, "timeout", 5*time.Minute, "")
+	flags.BoolVar(&f.waitForJobs, "wait-for-jobs", false, "")
+	flags.BoolVar(&f.atomic, "atomic", false, "")
+	flags.BoolVar(&f.strict, "strict", false, "")
+	flags.BoolVar(&f.skipRefresh, "skip-refresh", false, "")
+	flags.StringSliceVarP(&f.values.ValueFiles, "values", "f", nil, "")
+	flags.StringArrayVar(&f.values.Values, "set", nil, "")
+	flags.StringArrayVar(&f.values.StringValues, "set-string", nil, "")
+	flags.StringArrayVar(&f.values.FileValues, "set-file", nil, "")
+	flags.StringArrayVar(&f.values.JSONValues, "set-json", nil, "")
+	flags.StringArrayVar(&f.values.LiteralValues, "set-literal", nil, "")
+	flags.StringVar(&f.username, "username", "", "")
+	flags.StringVar(&f.password, "password", "", "")
+	flags.BoolVar(&f.passCredentials, "pass-credentials", false, "")
+	flags.StringVar(&f.caFile, "ca-file", "", "")
+	flags.StringVar(&f.certFile, "cert-file", "", "")
+	flags.StringVar(&f.keyFile, "key-file", "", "")
+	flags.BoolVar(&f.insecureSkipTLSVerify, "insecure-skip-tls-verify", false, "")
+	flags.BoolVar(&f.plainHTTP, "plain-http", false, "")
+	// Releases are always installed and upgraded with --wait, so the flag is accepted but has no effect
+	flags.Bool("wait", true, "")
+
+	if err := flags.Parse(allArgs); err != nil {
+		return nil, fmt.Errorf("unsupported Helm arguments %q: %w", strings.Join(allArgs, " "), err)
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unsupported Helm arguments %q: unexpected %q", strings.Join(allArgs, " "), flags.Args())
+	}
+	return f, nil
+}
+
+func (h HelmSDK) AddRepo(name string, url string, extraArgs []string) error {
+	const ociPrefix string = "oci://"
+
+	f, err := parseHelmFlags(extraArgs)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(url, ociPrefix) {
+		registryDomain := url[len(ociPrefix):]
+		registryClient, err := h.registryClient()
+		if err != nil {
+			return err
+		}
+		login := action.NewRegistryLogin(&action.Configuration{RegistryClient: registryClient})
+		return login.Run(h.out, registryDomain, f.username, f.password,
+			action.WithCertFile(f.certFile), action.WithKeyFile(f.keyFile), action.WithCAFile(f.caFile),
+			action.WithInsecure(f.insecureSkipTLSVerify), action.WithPlainHTTPLogin(f.plainHTTP))
+	}
+
+	entry := &repo.Entry{
+		Name:                  name,
+		URL:                   url,
+		Username:              f.username,
+		Password:              f.password,
+		PassCredentialsAll:    f.passCredentials,
+		CAFile:                f.caFile,
+		CertFile:              f.certFile,
+		KeyFile:               f.keyFile,
+		InsecureSkipTLSverify: f.insecureSkipTLSVerify,
+	}
+	chartRepo, err := repo.NewChartRepository(entry, getter.All(h.settings))
+	if err != nil {
+		return fmt.Errorf("failed adding repository %q: %w", name, err)
+	}
+	chartRepo.CachePath = h.settings.RepositoryCache
+	if _, err := chartRepo.DownloadIndexFile(); err != nil {
+		return fmt.Errorf("looks like %q is not a valid chart repository or cannot be reached: %w", url, err)
+	}
+
+	repoFile, err := repo.LoadFile(h.settings.RepositoryConfig)
+	if errors.Is(err, fs.ErrNotExist) {
+		repoFile = repo.NewFile()
+	} else if err != nil {
+		return fmt.Errorf("failed loading repository config: %w", err)
+	}
+	repoFile.Update(entry)
+	if err := os.MkdirAll(filepath.Dir(h.settings.RepositoryConfig), 0755); err != nil {
+		return err
+	}
+	if err := repoFile.WriteFile(h.settings.RepositoryConfig, 0600); err != nil {
+		return fmt.Errorf("failed writing repository config: %w", err)
+	}
+	fmt.Fprintf(h.out, "%q has been added to your repositories\n", name)
+	return nil
+}
+
+func (h HelmSDK) BuildDependencies(chart string) error {
+	return h.BuildDependenciesWithArgs(chart, []string{})
+}
+
+func (h HelmSDK) BuildDependenciesWithArgs(chart string, extraArgs []string) error {
+	f, err := parseHelmFlags(extraArgs)
+	if err != nil {
+		return err
+	}
+	registryClient, err := h.registryClient()
+	if err != nil {
+		return err
+	}
+
+	manager := &downloader.Manager{
+		Out:              h.out,
+		ChartPath:        chart,
+		SkipUpdate:       f.skipRefresh,
+		Getters:          getter.All(h.settings),
+		RegistryClient:   registryClient,
+		RepositoryConfig: h.settings.RepositoryConfig,
+		RepositoryCache:  h.settings.RepositoryCache,
+		Debug:            h.settings.Debug,
+	}
+	if err := manager.Build(); err != nil {
+		return fmt.Errorf("failed building dependencies of %s: %w", chart, err)
+	}
+	return nil
+}
+
+func (h HelmSDK) LintWithValues(chart string, valuesFile string) error {
+	f, err := parseHelmFlags(h.lintExtraArgs)
+	if err != nil {
+		return err
+	}
+	vals, err := h.values(f, valuesFile)
+	if err != nil {
+		return err
+	}
+
+	lint := action.NewLint()
+	lint.Strict = f.strict
+	result := lint.Run([]string{chart}, vals)
+
+	fmt.Fprintf(h.out, "==> Linting %s\n", chart)
+	for _, message := range result.Messages {
+		fmt.Fprintln(h.out, message)
+	}
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(h.out, "Error: 1 chart(s) linted, 1 chart(s) failed\n")
+		return fmt.Errorf("chart %s failed linting: %w", chart, errors.Join(result.Errors...))
+	}
+	fmt.Fprintf(h.out, "\n1 chart(s) linted, 0 chart(s) failed\n")
+	return nil
+}
+
+func (h HelmSDK) InstallWithValues(chart string, valuesFile string, namespace string, release string) error {
+	f, err := parseHelmFlags(h.extraArgs, h.extraSetArgs)
+	if err != nil {
+		return err
+	}
+	chrt, vals, err := h.loadChart(chart, f, valuesFile)
+	if err != nil {
+		return err
+	}
+	cfg, err := h.newActionConfig(namespace)
+	if err != nil {
+		return err
+	}
+
+	install := action.NewInstall(cfg)
+	install.ReleaseName = release
+	install.Namespace = namespace
+	install.Wait = true
+	install.WaitForJobs = f.waitForJobs
+	install.Timeout = f.timeout
+	install.Atomic = f.atomic
+	rel, err := install.RunWithContext(h.context(), chrt, vals)
+	if err != nil {
+		return fmt.Errorf("failed installing release %q: %w", release, err)
+	}
+	h.printRelease(rel)
+	return nil
+}
+
+func (h HelmSDK) UpgradeWithValues(chart string, valuesFile string, namespace string, release string) error {
+	f, err := parseHelmFlags(h.extraArgs, h.extraSetArgs)
+	if err != nil {
+		return err
+	}
+	chrt, vals, err := h.loadChart(chart, f, valuesFile)
+	if err != nil {
+		return err
+	}
+	cfg, err := h.newActionConfig(namespace)
+	if err != nil {
+		return err
+	}
+
+	upgrade := action.NewUpgrade(cfg)
+	upgrade.Namespace = namespace
+	upgrade.Wait = true
+	upgrade.WaitForJobs = f.waitForJobs
+	upgrade.Timeout = f.timeout
+	upgrade.Atomic = f.atomic
+	rel, err := upgrade.RunWithContext(h.context(), release, chrt, vals)
+	if err != nil {
+		return fmt.Errorf("failed upgrading release %q: %w", release, err)
+	}
+	h.printRelease(rel)
+	return nil
+}
+
+func (h HelmSDK) Rollback(namespace string, release string) error {
+	f, err := parseHelmFlags(h.extraArgs)
+	if err != nil {
+		return err
+	}
+	cfg, err := h.newActionConfig(namespace)
+	if err != nil {
+		return err
+	}
+
+	// Revision 0 rolls back to the previous revision
+	rollback := action.NewRollback(cfg)
+	rollback.Wait = true
+	rollback.WaitForJobs = f.waitForJobs
+	rollback.Timeout = f.timeout
+	if err := rollback.Run(release); err != nil {
+		return fmt.Errorf("failed rolling back release %q: %w", release, err)
+	}
+	fmt.Fprintln(h.out, "Rollback was a success! Happy Helming!")
+	return nil
+}
+
+func (h HelmSDK) Test(namespace string, release string) error {
+	f, err := parseHelmFlags(h.extraArgs)
+	if err != nil {
+		return err
+	}
+	cfg, err := h.newActionConfig(namespace)
+	if err != nil {
+		return err
+	}
+
+	test := action.NewReleaseTesting(cfg)
+	test.Namespace = namespace
+	test.Timeout = f.timeout
+	rel, runErr := test.Run(release)
+	if rel != nil {
+		h.printRelease(rel)
+		for _, hook := range rel.Hooks {
+			if !isTestHook(hook) {
+				continue
+			}
+			fmt.Fprintf(h.out, "TEST SUITE:     %s\n", hook.Name)
+			fmt.Fprintf(h.out, "Last Started:   %s\n", hook.LastRun.StartedAt.Format(time.ANSIC))
+			fmt.Fprintf(h.out, "Last Completed: %s\n", hook.LastRun.CompletedAt.Format(time.ANSIC))
+			fmt.Fprintf(h.out, "Phase:          %s\n", hook.LastRun.Phase)
+		}
+	}
+	if runErr != nil {
+		return fmt.Errorf("failed testing release %q: %w", release, runErr)
+	}
+	return nil
+}
+
+func isTestHook(hook *release.Hook) bool {
+	for _, event := range hook.Events {
+		if event == release.HookTest {
+			return true
+		}
+	}
+	return false
+}
+
+func (h HelmSDK) DeleteRelease(namespace string, release string) error {
+	fmt.Fprintf(h.out, "Deleting release %q...\n", release)
+	if err := h.uninstall(namespace, release); err != nil {
+		return fmt.Errorf("failed deleting release %q: %w", release, err)
+	}
+	return nil
+}
+
+func (h HelmSDK) uninstall(namespace string, release string) error {
+	f, err := parseHelmFlags(h.extraArgs)
+	if err != nil {
+		return err
+	}
+	cfg, err := h.newActionConfig(namespace)
+	if err != nil {
+		return err
+	}
+
+	uninstall := action.NewUninstall(cfg)
+	uninstall.Wait = true
+	uninstall.Timeout = f.timeout
+	if _, err := uninstall.Run(release); err != nil {
+		return err
+	}
+	fmt.Fprintf(h.out, "release %q uninstalled\n", release)
+	return nil
+}
+
+// Template renders chart the way `helm template` does, that is with the release name "release-name" in the namespace
+// "default", and returns the manifests of the resources and hooks.
+func (h HelmSDK) Template(chart string, valuesFile string, kubeVersion string) (string, error) {
+	f, err := parseHelmFlags(h.extraSetArgs)
+	if err != nil {
+		return "", err
+	}
+	chrt, vals, err := h.loadChart(chart, f, valuesFile)
+	if err != nil {
+		return "", err
+	}
+
+	install := action.NewInstall(&action.Configuration{Log: h.debugf})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = "release-name"
+	install.Namespace = "default"
+	if kubeVersion != "" {
+		install.KubeVersion, err = chartutil.ParseKubeVersion(kubeVersion)
+		if err != nil {
+			return "", fmt.Errorf("invalid Kubernetes version %q: %w", kubeVersion, err)
+		}
+	}
+	rel, err := install.Run(chrt, vals)
+	if err != nil {
+		return "", fmt.Errorf("failed rendering chart %s: %w", chart, err)
+	}
+
+	var manifest strings.Builder
+	manifest.WriteString(rel.Manifest)
+	for _, hook := range rel.Hooks {
+		fmt.Fprintf(&manifest, "---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+	}
+	return manifest.String(), nil
+}
+
+func (h HelmSDK) Pull(chart string, version string, destDir string) error {
+	registryClient, err := h.registryClient()
+	if err != nil {
+		return err
+	}
+
+	pull := action.NewPullWithOpts(action.WithConfig(&action.Configuration{RegistryClient: registryClient, Log: h.debugf}))
+	pull.Settings = h.settings
+	pull.Version = version
+	pull.Untar = true
+	pull.UntarDir = destDir
+	pull.DestDir = destDir
+	out, err := pull.Run(chart)
+	fmt.Fprint(h.out, out)
+	if err != nil {
+		return fmt.Errorf("failed pulling chart %s: %w", chart, err)
+	}
+	return nil
+}
+
+func (h HelmSDK) GetManifest(namespace string, release string) (string, error) {
+	cfg, err := h.newActionConfig(namespace)
+	if err != nil {
+		return "", err
+	}
+	rel, err := action.NewGet(cfg).Run(release)
+	if err != nil {
+		return "", fmt.Errorf("failed getting release %q: %w", release, err)
+	}
+	return rel.Manifest, nil
+}
+
+func (h HelmSDK) ListReleases(namespace string) ([]string, error) {
+	cfg, err := h.newActionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	list := action.NewList(cfg)
+	list.All = true
+	list.SetStateMask()
+	releases, err := list.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed listing releases in namespace %q: %w", namespace, err)
+	}
+	names := make([]string, 0, len(releases))
+	for _, rel := range releases {
+		names = append(names, rel.Name)
+	}
+	return names, nil
+}
+
+// Version returns the version of the Helm SDK ct has been built with.
+func (h HelmSDK) Version() (string, error) {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == helmModulePath {
+				return dep.Version, nil
+			}
+		}
+	}
+	return chartutil.DefaultCapabilities.HelmVersion.Version, nil
+}
+
+func (h HelmSDK) loadChart(chart string, f *helmFlags, valuesFile string) (*helmchart.Chart, map[string]any, error) {
+	chrt, err := loader.Load(chart)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed loading chart %s: %w", chart, err)
+	}
+	if req := chrt.Metadata.Dependencies; req != nil {
+		if err := action.CheckDependencies(chrt, req); err != nil {
+			return nil, nil, fmt.Errorf("failed checking dependencies of chart %s: %w", chart, err)
+		}
+	}
+	vals, err := h.values(f, valuesFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return chrt, vals, nil
+}
+
+// values merges valuesFile and the values passed with extra arguments the way the Helm CLI does.
+func (h HelmSDK) values(f *helmFlags, valuesFile string) (map[string]any, error) {
+	options := f.values
+	if valuesFile != "" {
+		options.ValueFiles = append([]string{valuesFile}, options.ValueFiles...)
+	}
+	vals, err := options.MergeValues(getter.All(h.settings))
+	if err != nil {
+		return nil, fmt.Errorf("failed reading values: %w", err)
+	}
+	return vals, nil
+}
+
+func (h HelmSDK) printRelease(rel *release.Release) {
+	fmt.Fprintf(h.out, "NAME: %s\n", rel.Name)
+	fmt.Fprintf(h.out, "NAMESPACE: %s\n", rel.Namespace)
+	if rel.Info != nil {
+		fmt.Fprintf(h.out, "STATUS: %s\n", rel.Info.Status)
+	}
+	fmt.Fprintf(h.out, "REVISION: %d\n", rel.Version)
+}
+
+func (h HelmSDK) registryClient() (*registry.Client, error) {
+	registryClient, err := registry.NewClient(
+		registry.ClientOptDebug(h.settings.Debug),
+		registry.ClientOptWriter(h.out),
+		registry.ClientOptCredentialsFile(h.settings.RegistryConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating registry client: %w", err)
+	}
+	return registryClient, nil
+}
+
+func (h HelmSDK) debugf(format string, v ...any) {
+	if h.settings.Debug {
+		fmt.Fprintf(h.out, "[debug] "+format+"\n", v...)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+const sdkChart = "testdata/sdkchart"
+
+// newFakeHelmSDK returns a HelmSDK storing releases in memory and printing resources instead of creating them.
+func newFakeHelmSDK(out io.Writer, extraArgs []string) (HelmSDK, *storage.Storage) {
+	releases := storage.Init(driver.NewMemory())
+	h := NewHelmSDK(out, extraArgs, nil, nil)
+	h.actionConfig = func(namespace string) (*action.Configuration, error) {
+		return &action.Configuration{
+			Releases:     releases,
+			KubeClient:   &kubefake.PrintingKubeClient{Out: io.Discard},
+			Capabilities: chartutil.DefaultCapabilities,
+			Log:          func(string, ...any) {},
+		}, nil
+	}
+	return h, releases
+}
+
+func TestHelmSDKReleaseLifecycle(t *testing.T) {
+	var b strings.Builder
+	h, releases := newFakeHelmSDK(&b, []string{"--timeout", "1m", "--set", "greeting=hey"})
+
+	require.NoError(t, h.InstallWithValues(sdkChart, "", "foo", "bar"))
+	rel, err := releases.Last("bar")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", rel.Namespace)
+	assert.Equal(t, release.StatusDeployed, rel.Info.Status)
+	assert.Contains(t, rel.Manifest, `greeting: "hey"`)
+
+	releaseNames, err := h.ListReleases("foo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar"}, releaseNames)
+
+	require.NoError(t, h.UpgradeWithValues(sdkChart, "testdata/sdkchart/ci/test-values.yaml", "foo", "bar"))
+	rel, err = releases.Last("bar")
+	require.NoError(t, err)
+	assert.Equal(t, 2, rel.Version)
+	// Values passed with --set take precedence over values files
+	assert.Contains(t, rel.Manifest, `greeting: "hey"`)
+
+	require.NoError(t, h.Rollback("foo", "bar"))
+	rel, err = releases.Last("bar")
+	require.NoError(t, err)
+	assert.Equal(t, 3, rel.Version)
+	assert.Equal(t, release.StatusDeployed, rel.Info.Status)
+
+	b.Reset()
+	require.NoError(t, h.Test("foo", "bar"))
+	assert.Contains(t, b.String(), "TEST SUITE:     bar-test\n")
+	assert.Contains(t, b.String(), "Phase:          Succeeded\n")
+
+	b.Reset()
+	require.NoError(t, h.DeleteRelease("foo", "bar"))
+	assert.Equal(t, "Deleting release \"bar\"...\nrelease \"bar\" uninstalled\n", b.String())
+	_, err = releases.Last("bar")
+	assert.Error(t, err)
+}
+
+func TestHelmSDKInstallFailure(t *testing.T) {
+	h, _ := newFakeHelmSDK(io.Discard, []string{"--set", "greeting="})
+
+	err := h.InstallWithValues(sdkChart, "", "foo", "bar")
+	assert.ErrorContains(t, err, "greeting is required")
+}
+
+func TestHelmSDKLintWithValues(t *testing.T) {
+	var b strings.Builder
+	h := NewHelmSDK(&b, nil, []string{"--strict"}, nil)
+
+	require.NoError(t, h.LintWithValues(sdkChart, "testdata/sdkchart/ci/test-values.yaml"))
+	assert.Contains(t, b.String(), "1 chart(s) linted, 0 chart(s) failed")
+
+	b.Reset()
+	err := h.LintWithValues("testdata/missing", "")
+	assert.ErrorContains(t, err, "chart testdata/missing failed linting")
+	assert.Contains(t, b.String(), "1 chart(s) linted, 1 chart(s) failed")
+}
+
+func TestParseHelmFlags(t *testing.T) {
+	f, err := parseHelmFlags([]string{"--timeout=2m", "--wait", "--atomic"}, []string{"--set", "a=b", "-f", "values.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, f.timeout)
+	assert.True(t, f.atomic)
+	assert.Equal(t, []string{"a=b"}, f.values.Values)
+	assert.Equal(t, []string{"values.yaml"}, f.values.ValueFiles)
+
+	_, err = parseHelmFlags([]string{"--post-renderer", "foo"})
+	assert.EqualError(t, err, `unsupported Helm arguments "--post-renderer foo": unknown flag: --post-renderer`)
+}
+
+func TestHelmSDKTemplate(t *testing.T) {
+	h := NewHelmSDK(io.Discard, nil, nil, []string{"--set", "greeting=hey"})
+
+	manifest, err := h.Template(sdkChart, "", "1.29.0")
+	require.NoError(t, err)
+	assert.Contains(t, manifest, "# Source: sdkchart/templates/configmap.yaml\n")
+	assert.Contains(t, manifest, "  name: release-name\n")
+	assert.Contains(t, manifest, `greeting: "hey"`)
+	assert.Contains(t, manifest, "---\n# Source: sdkchart/templates/tests/test-greeting.yaml\n")
+}
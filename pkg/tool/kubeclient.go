@@ -0,0 +1,639 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	namespaceTerminationTimeout = 180 * time.Second
+	namespaceForceDeleteTimeout = 5 * time.Second
+	namespacePollInterval       = time.Second
+)
+
+// NewKubeClient creates a Kubernetes client for the given context of the kubeconfig, or its current context if
+// kubeContext is empty. The kubeconfig is loaded the same way kubectl loads it.
+func NewKubeClient(kubeContext string) (kubernetes.Interface, error) {
+	restConfig, err := kubeClientConfig(kubeContext).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed loading kubeconfig: %w", err)
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// KubeconfigNamespace returns the namespace set for the given context of the kubeconfig, or its current context if
+// kubeContext is empty. Like with kubectl, it is 'default' if the context doesn't set one.
+func KubeconfigNamespace(kubeContext string) (string, error) {
+	namespace, _, err := kubeClientConfig(kubeContext).Namespace()
+	if err != nil {
+		return "", fmt.Errorf("failed loading kubeconfig: %w", err)
+	}
+	return namespace, nil
+}
+
+func kubeClientConfig(kubeContext string) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// ClientGoKubectl implements the operations of Kubectl with client-go instead of running kubectl. The client is
+// created on first use, so that commands which don't talk to a cluster don't need a kubeconfig.
+type ClientGoKubectl struct {
+	clientset func() (kubernetes.Interface, error)
+	// namespace returns the namespace of requests which don't specify one
+	namespace        func() (string, error)
+	out              io.Writer
+	timeout          time.Duration
+	readinessTimeout time.Duration
+	// namespaceTimeout is the time to wait for a namespace to terminate, forceDeleteTimeout the time to wait after each
+	// attempt to force its deletion
+	namespaceTimeout   time.Duration
+	forceDeleteTimeout time.Duration
+	ctx                context.Context
+}
+
+func NewClientGoKubectl(clientset func() (kubernetes.Interface, error), out io.Writer, timeout time.Duration, readinessTimeout time.Duration) ClientGoKubectl {
+	return ClientGoKubectl{
+		clientset:          clientset,
+		namespace:          func() (string, error) { return metav1.NamespaceDefault, nil },
+		out:                out,
+		timeout:            timeout,
+		readinessTimeout:   readinessTimeout,
+		namespaceTimeout:   namespaceTerminationTimeout,
+		forceDeleteTimeout: namespaceForceDeleteTimeout,
+	}
+}
+
+// WithContext returns a copy of k whose API requests are canceled once ctx is done.
+func (k ClientGoKubectl) WithContext(ctx context.Context) ClientGoKubectl {
+	k.ctx = ctx
+	return k
+}
+
+// WithDefaultNamespace returns a copy of k which uses the namespace returned by namespace for requests which don't
+// specify one, e.g. the namespace returned by KubeconfigNamespace.
+func (k ClientGoKubectl) WithDefaultNamespace(namespace func() (string, error)) ClientGoKubectl {
+	k.namespace = namespace
+	return k
+}
+
+// CreateNamespace creates a new namespace with the given name, labels, and annotations.
+func (k ClientGoKubectl) CreateNamespace(namespace string, labels map[string]string, annotations map[string]string) error {
+	fmt.Fprintf(k.out, "Creating namespace %q...\n", namespace)
+	client, err := k.clientset()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := k.context()
+	defer cancel()
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: labels, Annotations: annotations}}
+	if _, err := client.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed creating namespace %q: %w", namespace, err)
+	}
+	return nil
+}
+
+// CreateObjects creates the given ResourceQuotas, LimitRanges, and NetworkPolicies in namespace.
+func (k ClientGoKubectl) CreateObjects(namespace string, objects []runtime.Object) error {
+	client, err := k.clientset()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := k.context()
+	defer cancel()
+
+	for _, object := range objects {
+		var name string
+		switch o := object.(type) {
+		case *corev1.ResourceQuota:
+			name = "resourcequota/" + o.Name
+			_, err = client.CoreV1().ResourceQuotas(namespace).Create(ctx, o, metav1.CreateOptions{})
+		case *corev1.LimitRange:
+			name = "limitrange/" + o.Name
+			_, err = client.CoreV1().LimitRanges(namespace).Create(ctx, o, metav1.CreateOptions{})
+		case *networkingv1.NetworkPolicy:
+			name = "networkpolicy/" + o.Name
+			_, err = client.NetworkingV1().NetworkPolicies(namespace).Create(ctx, o, metav1.CreateOptions{})
+		default:
+			return fmt.Errorf("cannot create object of type %T", object)
+		}
+		if err != nil {
+			return fmt.Errorf("failed creating %s in namespace %q: %w", name, namespace, err)
+		}
+	}
+	return nil
+}
+
+// GetNamespaces returns the namespaces matching the label selector.
+func (k ClientGoKubectl) GetNamespaces(selector string) ([]corev1.Namespace, error) {
+	client, err := k.clientset()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := k.context()
+	defer cancel()
+
+	list, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed listing namespaces: %w", err)
+	}
+	return list.Items, nil
+}
+
+// DeleteNamespace deletes the specified namespace. If the namespace does not terminate in time, the resources in the
+// namespace which 'kubectl delete all' deletes are force-deleted, as with kubectl, and, eventually, the finalizers of
+// the namespace are removed. An error is returned if the namespace still exists afterwards.
+func (k ClientGoKubectl) DeleteNamespace(namespace string) error {
+	fmt.Fprintf(k.out, "Deleting namespace %q...\n", namespace)
+	client, err := k.clientset()
+	if err != nil {
+		return fmt.Errorf("failed deleting namespace %q: %w", namespace, err)
+	}
+
+	ctx, cancel := k.context()
+	err = client.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{})
+	cancel()
+	if apierrors.IsNotFound(err) {
+		fmt.Fprintf(k.out, "Namespace %q terminated.\n", namespace)
+		return nil
+	} else if err != nil {
+		fmt.Fprintln(k.out, "Error deleting namespace:", err)
+	}
+	if k.waitForNamespaceDeletion(client, namespace, k.namespaceTimeout) {
+		return nil
+	}
+	fmt.Fprintf(k.out, "Namespace %q did not terminate after %s.\n", namespace, k.namespaceTimeout)
+
+	fmt.Fprintln(k.out, "Force-deleting everything...")
+	if err := k.forceDeleteAll(client, namespace); err != nil {
+		fmt.Fprintf(k.out, "Error deleting everything in the namespace %v: %v\n", namespace, err)
+	}
+	if k.waitForNamespaceDeletion(client, namespace, k.forceDeleteTimeout) {
+		return nil
+	}
+
+	return k.finalizeNamespace(client, namespace)
+}
+
+// forceDeleteAll force-deletes the resources of the 'all' category in namespace, i.e. the resources 'kubectl delete
+// all' deletes. Each kind is deleted with a timeout of its own, so that a slow deletion doesn't fail the ones after it.
+func (k ClientGoKubectl) forceDeleteAll(client kubernetes.Interface, namespace string) error {
+	gracePeriod := int64(0)
+	options := metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod}
+	var errs *multierror.Error
+	for _, resource := range []struct {
+		name             string
+		deleteCollection func(context.Context, metav1.DeleteOptions, metav1.ListOptions) error
+	}{
+		{"pods", client.CoreV1().Pods(namespace).DeleteCollection},
+		{"replicationcontrollers", client.CoreV1().ReplicationControllers(namespace).DeleteCollection},
+		{"services", func(ctx context.Context, options metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+			// Services cannot be deleted as a collection
+			services, err := client.CoreV1().Services(namespace).List(ctx, listOptions)
+			if err != nil {
+				return err
+			}
+			for _, service := range services.Items {
+				err := client.CoreV1().Services(namespace).Delete(ctx, service.Name, options)
+				if err != nil && !apierrors.IsNotFound(err) {
+					return err
+				}
+			}
+			return nil
+		}},
+		{"daemonsets", client.AppsV1().DaemonSets(namespace).DeleteCollection},
+		{"deployments", client.AppsV1().Deployments(namespace).DeleteCollection},
+		{"replicasets", client.AppsV1().ReplicaSets(namespace).DeleteCollection},
+		{"statefulsets", client.AppsV1().StatefulSets(namespace).DeleteCollection},
+		{"horizontalpodautoscalers", client.AutoscalingV2().HorizontalPodAutoscalers(namespace).DeleteCollection},
+		{"cronjobs", client.BatchV1().CronJobs(namespace).DeleteCollection},
+		{"jobs", client.BatchV1().Jobs(namespace).DeleteCollection},
+	} {
+		ctx, cancel := k.context()
+		err := resource.deleteCollection(ctx, options, metav1.ListOptions{})
+		cancel()
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed deleting %s: %w", resource.name, err))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// finalizeNamespace removes the finalizers of the namespace, which lets a namespace stuck in terminating state go.
+func (k ClientGoKubectl) finalizeNamespace(client kubernetes.Interface, namespace string) error {
+	fmt.Fprintf(k.out, "Removing finalizers from namespace %q...\n", namespace)
+	ctx, cancel := k.context()
+	defer cancel()
+
+	ns, err := client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	ns.Spec.Finalizers = nil
+	if _, err := client.CoreV1().Namespaces().Finalize(ctx, ns, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("cannot force-delete namespace %q: %w", namespace, err)
+	}
+
+	if k.waitForNamespaceDeletion(client, namespace, k.forceDeleteTimeout) {
+		return nil
+	}
+	return fmt.Errorf("namespace %q still exists after removing its finalizers", namespace)
+}
+
+// waitForNamespaceDeletion returns whether the namespace is gone within timeout.
+func (k ClientGoKubectl) waitForNamespaceDeletion(client kubernetes.Interface, namespace string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := k.context()
+		_, err := client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		cancel()
+		if apierrors.IsNotFound(err) {
+			fmt.Fprintf(k.out, "Namespace %q terminated.\n", namespace)
+			return true
+		}
+		if !time.Now().Add(namespacePollInterval).Before(deadline) || k.parent().Err() != nil {
+			return false
+		}
+		time.Sleep(namespacePollInterval)
+	}
+}
+
+// WaitForResources waits for the Deployments, StatefulSets, DaemonSets, Jobs, PersistentVolumeClaims, and Services of
+// type LoadBalancer matching selector to become ready. Resources are watched with informers, so readiness is
+// re-evaluated whenever one of them changes.
+func (k ClientGoKubectl) WaitForResources(namespace string, selector string) error {
+	fmt.Fprintf(k.out, "Waiting for resources in namespace %q to become ready...\n", namespace)
+	client, err := k.clientset()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(k.parent())
+	defer cancel()
+
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = selector
+		}))
+	resourceInformers := []cache.SharedIndexInformer{
+		factory.Apps().V1().Deployments().Informer(),
+		factory.Apps().V1().StatefulSets().Informer(),
+		factory.Apps().V1().DaemonSets().Informer(),
+		factory.Batch().V1().Jobs().Informer(),
+		factory.Core().V1().PersistentVolumeClaims().Informer(),
+		factory.Core().V1().Services().Informer(),
+	}
+
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { notify() },
+		UpdateFunc: func(any, any) { notify() },
+		DeleteFunc: func(any) { notify() },
+	}
+	for _, informer := range resourceInformers {
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return err
+		}
+	}
+
+	factory.Start(ctx.Done())
+	defer func() {
+		// The informers only stop once the context is canceled
+		cancel()
+		factory.Shutdown()
+	}()
+	for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("failed listing %v in namespace %q", informerType, namespace)
+		}
+	}
+
+	return waitForResources(k.parent(), k.out, k.readinessTimeout, changed, func() ([]resourceStatus, error) {
+		var statuses []resourceStatus
+		for _, informer := range resourceInformers {
+			for _, object := range informer.GetStore().List() {
+				statuses = append(statuses, readiness(object))
+			}
+		}
+		slices.SortFunc(statuses, func(a, b resourceStatus) int {
+			return strings.Compare(a.Resource, b.Resource)
+		})
+		return statuses, nil
+	})
+}
+
+func (k ClientGoKubectl) GetPodsforDeployment(namespace string, deployment string) ([]string, error) {
+	client, err := k.clientset()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := k.context()
+	defer cancel()
+
+	d, err := client.AppsV1().Deployments(namespace).Get(ctx, deployment, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	return k.GetPods("--selector", selector.String(), "--namespace", namespace)
+}
+
+// GetPods gets the names of pods. Of the given kubectl arguments, '--namespace', '--selector', pod names,
+// '--no-headers', and an '--output' printing the names of the pods are supported. Other arguments result in an error.
+// Without '--namespace', the default namespace is used.
+func (k ClientGoKubectl) GetPods(args ...string) ([]string, error) {
+	namespace, selector, names, err := parsePodArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		if namespace, err = k.namespace(); err != nil {
+			return nil, err
+		}
+	}
+	client, err := k.clientset()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := k.context()
+	defer cancel()
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, pod := range pods.Items {
+		if len(names) == 0 || slices.Contains(names, pod.Name) {
+			result = append(result, pod.Name)
+		}
+	}
+	return result, nil
+}
+
+// podNamesOutput is the only '--output' supported by GetPods, since it only returns the names of pods.
+const podNamesOutput = "jsonpath={.items[*].metadata.name}"
+
+func parsePodArgs(args []string) (namespace string, selector string, names []string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		flag, value, hasValue := strings.Cut(arg, "=")
+		switch flag {
+		case "--namespace", "-n", "--selector", "-l", "--output", "-o":
+			if !hasValue {
+				if i+1 == len(args) {
+					return "", "", nil, fmt.Errorf("flag %q needs a value", flag)
+				}
+				i++
+				value = args[i]
+			}
+			switch flag {
+			case "--namespace", "-n":
+				namespace = value
+			case "--selector", "-l":
+				selector = value
+			default:
+				if value != podNamesOutput {
+					return "", "", nil, fmt.Errorf("unsupported output %q, only %q is supported", value, podNamesOutput)
+				}
+			}
+		case "--no-headers":
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return "", "", nil, fmt.Errorf("unsupported flag %q", arg)
+			}
+			names = append(names, arg)
+		}
+	}
+	return namespace, selector, names, nil
+}
+
+// GetEvents prints all events of namespace sorted by the time they were last seen.
+func (k ClientGoKubectl) GetEvents(namespace string) error {
+	client, err := k.clientset()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := k.context()
+	defer cancel()
+
+	events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	printEvents(k.out, events.Items)
+	return nil
+}
+
+func printEvents(out io.Writer, events []corev1.Event) {
+	if len(events) == 0 {
+		fmt.Fprintln(out, "No events found.")
+		return
+	}
+	slices.SortStableFunc(events, func(a, b corev1.Event) int {
+		return a.LastTimestamp.Compare(b.LastTimestamp.Time)
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE")
+	for _, event := range events {
+		object := fmt.Sprintf("%s/%s", strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", event.LastTimestamp.Format(time.RFC3339), event.Type, event.Reason,
+			object, strings.TrimSpace(event.Message))
+	}
+	w.Flush()
+}
+
+// DescribePod prints the status, conditions, containers, and events of the pod.
+func (k ClientGoKubectl) DescribePod(namespace string, pod string) error {
+	client, err := k.clientset()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := k.context()
+	defer cancel()
+
+	p, err := client.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(k.out, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", p.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", p.Namespace)
+	fmt.Fprintf(w, "Node:\t%s\n", p.Spec.NodeName)
+	fmt.Fprintf(w, "Status:\t%s\n", p.Status.Phase)
+	if p.Status.Reason != "" {
+		fmt.Fprintf(w, "Reason:\t%s\n", p.Status.Reason)
+	}
+	if p.Status.Message != "" {
+		fmt.Fprintf(w, "Message:\t%s\n", p.Status.Message)
+	}
+	fmt.Fprintf(w, "IP:\t%s\n", p.Status.PodIP)
+	w.Flush()
+
+	printContainerStatuses(k.out, "Init Containers", p.Spec.InitContainers, p.Status.InitContainerStatuses)
+	printContainerStatuses(k.out, "Containers", p.Spec.Containers, p.Status.ContainerStatuses)
+
+	fmt.Fprintln(k.out, "Conditions:")
+	for _, condition := range p.Status.Conditions {
+		fmt.Fprintf(k.out, "  %s: %s\n", condition.Type, condition.Status)
+	}
+
+	events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var podEvents []corev1.Event
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind == "Pod" && event.InvolvedObject.Name == pod {
+			podEvents = append(podEvents, event)
+		}
+	}
+	fmt.Fprintln(k.out, "Events:")
+	printEvents(k.out, podEvents)
+	return nil
+}
+
+func printContainerStatuses(out io.Writer, title string, containers []corev1.Container, statuses []corev1.ContainerStatus) {
+	if len(containers) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s:\n", title)
+	for _, container := range containers {
+		fmt.Fprintf(out, "  %s:\n", container.Name)
+		fmt.Fprintf(out, "    Image: %s\n", container.Image)
+		for _, status := range statuses {
+			if status.Name != container.Name {
+				continue
+			}
+			switch {
+			case status.State.Waiting != nil:
+				fmt.Fprintf(out, "    State: Waiting (%s) %s\n", status.State.Waiting.Reason, status.State.Waiting.Message)
+			case status.State.Running != nil:
+				fmt.Fprintf(out, "    State: Running since %s\n", status.State.Running.StartedAt.Format(time.RFC3339))
+			case status.State.Terminated != nil:
+				fmt.Fprintf(out, "    State: Terminated (%s) with exit code %d\n", status.State.Terminated.Reason,
+					status.State.Terminated.ExitCode)
+			}
+			fmt.Fprintf(out, "    Ready: %t\n", status.Ready)
+			fmt.Fprintf(out, "    Restart Count: %d\n", status.RestartCount)
+		}
+	}
+}
+
+// Logs prints the logs of container.
+func (k ClientGoKubectl) Logs(namespace string, pod string, container string) error {
+	return k.logs(namespace, pod, &corev1.PodLogOptions{Container: container})
+}
+
+func (k ClientGoKubectl) PreviousLogs(namespace string, pod string, container string) error {
+	return k.logs(namespace, pod, &corev1.PodLogOptions{Container: container, Previous: true})
+}
+
+// logs prints the logs of a container. Unlike other requests, streaming the logs isn't bound to the configured timeout,
+// since printing long logs may take longer than that; it is only canceled with the run.
+func (k ClientGoKubectl) logs(namespace string, pod string, options *corev1.PodLogOptions) error {
+	client, err := k.clientset()
+	if err != nil {
+		return err
+	}
+
+	stream, err := client.CoreV1().Pods(namespace).GetLogs(pod, options).Stream(k.parent())
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	_, err = io.Copy(k.out, stream)
+	return err
+}
+
+func (k ClientGoKubectl) GetInitContainers(namespace string, pod string) ([]string, error) {
+	p, err := k.getPod(namespace, pod)
+	if err != nil {
+		return nil, err
+	}
+	return containerNames(p.Spec.InitContainers), nil
+}
+
+func (k ClientGoKubectl) GetContainers(namespace string, pod string) ([]string, error) {
+	p, err := k.getPod(namespace, pod)
+	if err != nil {
+		return nil, err
+	}
+	return containerNames(p.Spec.Containers), nil
+}
+
+func (k ClientGoKubectl) getPod(namespace string, pod string) (*corev1.Pod, error) {
+	client, err := k.clientset()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := k.context()
+	defer cancel()
+	return client.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+}
+
+func containerNames(containers []corev1.Container) []string {
+	names := make([]string, 0, len(containers))
+	for _, container := range containers {
+		names = append(names, container.Name)
+	}
+	return names
+}
+
+// context returns a context for a single API request, which is canceled after the configured timeout.
+func (k ClientGoKubectl) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(k.parent(), k.timeout)
+}
+
+// parent returns the context all API requests are bound to.
+func (k ClientGoKubectl) parent() context.Context {
+	if k.ctx == nil {
+		return context.Background()
+	}
+	return k.ctx
+}
@@ -0,0 +1,297 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func newFakeClientGoKubectl(client kubernetes.Interface, out io.Writer) ClientGoKubectl {
+	k := NewClientGoKubectl(func() (kubernetes.Interface, error) { return client, nil }, out, 10*time.Second, 10*time.Second)
+	k.namespaceTimeout = 0
+	k.forceDeleteTimeout = 0
+	return k
+}
+
+func TestClientGoKubectlNamespaces(t *testing.T) {
+	client := fake.NewClientset()
+	var b strings.Builder
+	k := newFakeClientGoKubectl(client, &b)
+
+	labels := map[string]string{"app.kubernetes.io/managed-by": "chart-testing"}
+	annotations := map[string]string{"chart-testing.helm.sh/build-id": "pr-42"}
+	require.NoError(t, k.CreateNamespace("foo", labels, annotations))
+	require.NoError(t, k.CreateNamespace("bar", nil, nil))
+
+	namespaces, err := k.GetNamespaces("app.kubernetes.io/managed-by=chart-testing")
+	require.NoError(t, err)
+	require.Len(t, namespaces, 1)
+	assert.Equal(t, "foo", namespaces[0].Name)
+	assert.Equal(t, annotations, namespaces[0].Annotations)
+
+	require.NoError(t, k.DeleteNamespace("foo"))
+	_, err = client.CoreV1().Namespaces().Get(context.Background(), "foo", metav1.GetOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, "Creating namespace \"foo\"...\nCreating namespace \"bar\"...\nDeleting namespace \"foo\"...\nNamespace \"foo\" terminated.\n", b.String())
+}
+
+func TestClientGoKubectlCreateObjects(t *testing.T) {
+	client := fake.NewClientset()
+	k := newFakeClientGoKubectl(client, io.Discard)
+	objectMeta := metav1.ObjectMeta{Name: "chart-testing"}
+
+	require.NoError(t, k.CreateObjects("foo", []runtime.Object{
+		&corev1.ResourceQuota{ObjectMeta: objectMeta},
+		&corev1.LimitRange{ObjectMeta: objectMeta},
+		&networkingv1.NetworkPolicy{ObjectMeta: objectMeta},
+	}))
+	_, err := client.CoreV1().ResourceQuotas("foo").Get(context.Background(), "chart-testing", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = client.CoreV1().LimitRanges("foo").Get(context.Background(), "chart-testing", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = client.NetworkingV1().NetworkPolicies("foo").Get(context.Background(), "chart-testing", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	err = k.CreateObjects("foo", []runtime.Object{&corev1.ResourceQuota{ObjectMeta: objectMeta}})
+	assert.ErrorContains(t, err, `failed creating resourcequota/chart-testing in namespace "foo"`)
+	err = k.CreateObjects("foo", []runtime.Object{&corev1.ConfigMap{ObjectMeta: objectMeta}})
+	assert.ErrorContains(t, err, "cannot create object of type *v1.ConfigMap")
+}
+
+func TestClientGoKubectlFinalizesStuckNamespace(t *testing.T) {
+	client := fake.NewClientset(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec:       corev1.NamespaceSpec{Finalizers: []corev1.FinalizerName{"kubernetes"}},
+		},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "foo"}},
+	)
+	// The namespace is stuck in terminating state until its finalizers are removed
+	client.PrependReactor("delete", "namespaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, nil
+	})
+	var finalized *corev1.Namespace
+	client.PrependReactor("create", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "finalize" {
+			return false, nil, nil
+		}
+		finalized = action.(k8stesting.CreateAction).GetObject().(*corev1.Namespace)
+		return true, finalized, client.Tracker().Delete(action.GetResource(), "", finalized.Name)
+	})
+
+	var b strings.Builder
+	k := newFakeClientGoKubectl(client, &b)
+	require.NoError(t, k.DeleteNamespace("foo"))
+
+	require.NotNil(t, finalized)
+	assert.Empty(t, finalized.Spec.Finalizers)
+	// The fake clientset doesn't implement deleting collections, so the requests are checked instead
+	var deleted []string
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "delete-collection" && action.GetNamespace() == "foo" {
+			deleted = append(deleted, action.GetResource().Resource)
+		}
+	}
+	assert.ElementsMatch(t, []string{"pods", "replicationcontrollers", "daemonsets", "deployments", "replicasets",
+		"statefulsets", "horizontalpodautoscalers", "cronjobs", "jobs"}, deleted)
+	services, err := client.CoreV1().Services("foo").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, services.Items)
+	assert.Contains(t, b.String(), "Force-deleting everything...\n")
+	assert.Contains(t, b.String(), "Removing finalizers from namespace \"foo\"...\nNamespace \"foo\" terminated.\n")
+}
+
+func TestClientGoKubectlWaitForResources(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/instance": "foo"}
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "foo", Labels: labels},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	otherRelease := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "foo"}}
+
+	t.Run("waits until resources are ready", func(t *testing.T) {
+		client := fake.NewClientset(deployment.DeepCopy(), otherRelease)
+		var b strings.Builder
+		// The deployment becomes ready once it has been reported as not ready
+		out := writerFunc(func(p []byte) (int, error) {
+			if strings.HasPrefix(string(p), "Waiting for deployment/web") {
+				ready := deployment.DeepCopy()
+				ready.Status = appsv1.DeploymentStatus{UpdatedReplicas: 1, AvailableReplicas: 1}
+				_, err := client.AppsV1().Deployments("foo").UpdateStatus(context.Background(), ready, metav1.UpdateOptions{})
+				assert.NoError(t, err)
+			}
+			return b.Write(p)
+		})
+		k := newFakeClientGoKubectl(client, out)
+
+		err := k.WaitForResources("foo", "app.kubernetes.io/instance=foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "Waiting for resources in namespace \"foo\" to become ready...\n"+
+			"Waiting for deployment/web: 0 of 1 replicas updated\n", b.String())
+	})
+
+	t.Run("fails for failed jobs", func(t *testing.T) {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "foo", Labels: labels},
+			Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+			}},
+		}
+		var b strings.Builder
+		k := newFakeClientGoKubectl(fake.NewClientset(job), &b)
+
+		err := k.WaitForResources("foo", "app.kubernetes.io/instance=foo")
+		assert.EqualError(t, err, "job/migrate failed: BackoffLimitExceeded")
+	})
+
+	t.Run("reports resources which are not ready", func(t *testing.T) {
+		var b strings.Builder
+		k := newFakeClientGoKubectl(fake.NewClientset(deployment.DeepCopy()), &b)
+		k.readinessTimeout = 500 * time.Millisecond
+
+		err := k.WaitForResources("foo", "app.kubernetes.io/instance=foo")
+		assert.EqualError(t, err, "timed out after 500ms waiting for resources to become ready: "+
+			"deployment/web: 0 of 1 replicas updated")
+	})
+}
+
+func TestClientGoKubectlPods(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "foo", Labels: map[string]string{"app": "web"}},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init"}},
+			Containers:     []corev1.Container{{Name: "web", Image: "nginx"}, {Name: "sidecar", Image: "envoy"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	otherPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-1", Namespace: "foo"}}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "foo"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web-1.1", Namespace: "foo"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-1"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+		Message:        "Back-off pulling image",
+		LastTimestamp:  metav1.NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)),
+	}
+	client := fake.NewClientset(pod, otherPod, deployment, event)
+	var b strings.Builder
+	k := newFakeClientGoKubectl(client, &b)
+
+	pods, err := k.GetPods("--no-headers", "--namespace", "foo", "--selector", "app=web", "--output",
+		"jsonpath={.items[*].metadata.name}")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web-1"}, pods)
+
+	pods, err = k.GetPodsforDeployment("foo", "web")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web-1"}, pods)
+
+	initContainers, err := k.GetInitContainers("foo", "web-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"init"}, initContainers)
+	containers, err := k.GetContainers("foo", "web-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web", "sidecar"}, containers)
+
+	require.NoError(t, k.Logs("foo", "web-1", "web"))
+	assert.Equal(t, "fake logs", b.String())
+
+	b.Reset()
+	require.NoError(t, k.GetEvents("foo"))
+	assert.Equal(t, "LAST SEEN              TYPE      REASON    OBJECT      MESSAGE\n"+
+		"2024-05-01T12:00:00Z   Warning   BackOff   pod/web-1   Back-off pulling image\n", b.String())
+
+	b.Reset()
+	require.NoError(t, k.DescribePod("foo", "web-1"))
+	assert.Contains(t, b.String(), "Name:      web-1\nNamespace: foo\n")
+	assert.Contains(t, b.String(), "Containers:\n  web:\n    Image: nginx\n")
+	assert.Contains(t, b.String(), "Events:\nLAST SEEN")
+}
+
+func TestParsePodArgs(t *testing.T) {
+	namespace, selector, names, err := parsePodArgs([]string{"web-1", "--no-headers", "-n", "foo", "--selector=app=web",
+		"--output", "jsonpath={.items[*].metadata.name}"})
+	require.NoError(t, err)
+	assert.Equal(t, "foo", namespace)
+	assert.Equal(t, "app=web", selector)
+	assert.Equal(t, []string{"web-1"}, names)
+
+	namespace, _, _, err = parsePodArgs([]string{"web-1"})
+	require.NoError(t, err)
+	assert.Empty(t, namespace)
+
+	_, _, _, err = parsePodArgs([]string{"web-1", "--all-namespaces"})
+	assert.ErrorContains(t, err, `unsupported flag "--all-namespaces"`)
+	_, _, _, err = parsePodArgs([]string{"web-1", "--output", "jsonpath={.spec.containers[*].name}"})
+	assert.ErrorContains(t, err, `unsupported output "jsonpath={.spec.containers[*].name}"`)
+	_, _, _, err = parsePodArgs([]string{"--namespace"})
+	assert.ErrorContains(t, err, `flag "--namespace" needs a value`)
+}
+
+func TestClientGoKubectlGetPodsDefaultNamespace(t *testing.T) {
+	client := fake.NewClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "foo"}},
+	)
+	k := newFakeClientGoKubectl(client, io.Discard)
+
+	pods, err := k.GetPods()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web-1"}, pods)
+
+	pods, err = k.WithDefaultNamespace(func() (string, error) { return "foo", nil }).GetPods()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web-2"}, pods)
+}
+
+func TestClientGoKubectlLogsOutlastTimeout(t *testing.T) {
+	client := fake.NewClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "foo"}})
+	var b strings.Builder
+	k := NewClientGoKubectl(func() (kubernetes.Interface, error) { return client, nil }, &b, time.Nanosecond, time.Second)
+
+	require.NoError(t, k.Logs("foo", "web-1", "web"))
+	assert.Equal(t, "fake logs", b.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, k.WithContext(ctx).Logs("foo", "web-1", "web"))
+}
@@ -5,100 +5,175 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/helm/chart-testing/v3/pkg/exec"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+const readinessPollInterval = 2 * time.Second
+
 type Kubectl struct {
-	exec    exec.ProcessExecutor
-	timeout time.Duration
+	exec             exec.ProcessExecutor
+	timeout          time.Duration
+	readinessTimeout time.Duration
+	kubeContext      string
 }
 
-func NewKubectl(exec exec.ProcessExecutor, timeout time.Duration) Kubectl {
+// NewKubectl creates a Kubectl which runs kubectl with the given request timeout and waits for resources to become
+// ready for at most readinessTimeout.
+func NewKubectl(exec exec.ProcessExecutor, timeout time.Duration, readinessTimeout time.Duration) Kubectl {
 	return Kubectl{
-		exec:    exec,
-		timeout: timeout,
+		exec:             exec,
+		timeout:          timeout,
+		readinessTimeout: readinessTimeout,
 	}
 }
 
-// CreateNamespace creates a new namespace with the given name.
-func (k Kubectl) CreateNamespace(namespace string) error {
-	fmt.Printf("Creating namespace %q...\n", namespace)
+// WithKubeContext returns a copy of k which operates on the given context of the kubeconfig.
+func (k Kubectl) WithKubeContext(kubeContext string) Kubectl {
+	k.kubeContext = kubeContext
+	return k
+}
+
+// CreateNamespace creates a new namespace with the given name, labels, and annotations.
+func (k Kubectl) CreateNamespace(namespace string, labels map[string]string, annotations map[string]string) error {
+	fmt.Fprintf(k.exec.Output(), "Creating namespace %q...\n", namespace)
+	if err := k.exec.RunProcess("kubectl",
+		k.globalArgs(),
+		"create", "namespace", namespace); err != nil {
+		return err
+	}
+	if len(labels) > 0 {
+		if err := k.exec.RunProcess("kubectl",
+			k.globalArgs(),
+			"label", "namespace", namespace, keyValueArgs(labels)); err != nil {
+			return fmt.Errorf("failed labeling namespace %q: %w", namespace, err)
+		}
+	}
+	if len(annotations) > 0 {
+		if err := k.exec.RunProcess("kubectl",
+			k.globalArgs(),
+			"annotate", "namespace", namespace, keyValueArgs(annotations)); err != nil {
+			return fmt.Errorf("failed annotating namespace %q: %w", namespace, err)
+		}
+	}
+	return nil
+}
+
+// CreateObjects creates the given objects in namespace. The objects must have their API version and kind set.
+func (k Kubectl) CreateObjects(namespace string, objects []runtime.Object) error {
+	data, err := json.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": objects})
+	if err != nil {
+		return fmt.Errorf("failed marshaling objects: %w", err)
+	}
+	file, err := os.CreateTemp("", "ct-objects-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
 	return k.exec.RunProcess("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
-		"create", "namespace", namespace)
+		k.globalArgs(),
+		"create", "--namespace", namespace, "--filename", file.Name())
+}
+
+// GetNamespaces returns the namespaces matching the label selector.
+func (k Kubectl) GetNamespaces(selector string) ([]corev1.Namespace, error) {
+	output, err := k.exec.RunProcessAndCaptureStdout("kubectl",
+		k.globalArgs(),
+		"get", "namespaces", "--selector", selector, "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var list corev1.NamespaceList
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("failed parsing namespaces: %w", err)
+	}
+	return list.Items, nil
 }
 
 // DeleteNamespace deletes the specified namespace. If the namespace does not terminate within 120s, pods running in the
-// namespace and, eventually, the namespace itself are force-deleted.
-func (k Kubectl) DeleteNamespace(namespace string) {
-	fmt.Printf("Deleting namespace %q...\n", namespace)
+// namespace and, eventually, the namespace itself are force-deleted. An error is returned if the namespace still exists
+// afterwards.
+func (k Kubectl) DeleteNamespace(namespace string) error {
+	fmt.Fprintf(k.exec.Output(), "Deleting namespace %q...\n", namespace)
 	timeoutSec := "180s"
 	err := k.exec.RunProcess("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
+		k.globalArgs(),
 		"delete", "namespace", namespace, "--timeout", timeoutSec)
 	if err != nil {
-		fmt.Printf("Namespace %q did not terminate after %s.\n", namespace, timeoutSec)
+		fmt.Fprintf(k.exec.Output(), "Namespace %q did not terminate after %s.\n", namespace, timeoutSec)
 	}
 
-	if k.getNamespace(namespace) {
-		fmt.Printf("Namespace %q did not terminate after %s.\n", namespace, timeoutSec)
+	if !k.getNamespace(namespace) {
+		return nil
+	}
+	fmt.Fprintf(k.exec.Output(), "Namespace %q did not terminate after %s.\n", namespace, timeoutSec)
 
-		fmt.Println("Force-deleting everything...")
-		err = k.exec.RunProcess("kubectl",
-			fmt.Sprintf("--request-timeout=%s", k.timeout),
-			"delete", "all", "--namespace", namespace, "--all", "--force",
-			"--grace-period=0")
-		if err != nil {
-			fmt.Printf("Error deleting everything in the namespace %v: %v", namespace, err)
-		}
+	fmt.Fprintln(k.exec.Output(), "Force-deleting everything...")
+	err = k.exec.RunProcess("kubectl",
+		k.globalArgs(),
+		"delete", "all", "--namespace", namespace, "--all", "--force",
+		"--grace-period=0")
+	if err != nil {
+		fmt.Fprintf(k.exec.Output(), "Error deleting everything in the namespace %v: %v\n", namespace, err)
+	}
 
-		// Give it some more time to be deleted by K8s
-		time.Sleep(5 * time.Second)
+	// Give it some more time to be deleted by K8s
+	time.Sleep(5 * time.Second)
 
-		if k.getNamespace(namespace) {
-			if err := k.forceNamespaceDeletion(namespace); err != nil {
-				fmt.Println("Error force deleting namespace:", err)
-			}
-		}
+	if k.getNamespace(namespace) {
+		return k.forceNamespaceDeletion(namespace)
 	}
+	return nil
 }
 
 func (k Kubectl) forceNamespaceDeletion(namespace string) error {
 	// Getting the namespace json to remove the finalizer
 	cmdOutput, err := k.exec.RunProcessAndCaptureStdout("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
+		k.globalArgs(),
 		"get", "namespace", namespace, "--output=json")
 	if err != nil {
-		fmt.Println("Error getting namespace json:", err)
+		fmt.Fprintln(k.exec.Output(), "Error getting namespace json:", err)
 		return err
 	}
 
 	namespaceUpdate := map[string]any{}
 	err = json.Unmarshal([]byte(cmdOutput), &namespaceUpdate)
 	if err != nil {
-		fmt.Println("Error in unmarshalling the payload:", err)
+		fmt.Fprintln(k.exec.Output(), "Error in unmarshalling the payload:", err)
 		return err
 	}
 	namespaceUpdate["spec"] = nil
 	namespaceUpdateBytes, err := json.Marshal(&namespaceUpdate)
 	if err != nil {
-		fmt.Println("Error in marshalling the payload:", err)
+		fmt.Fprintln(k.exec.Output(), "Error in marshalling the payload:", err)
 		return err
 	}
 
 	// Remove finalizer from the namespace
 	fun := func(port int) error {
-		fmt.Printf("Removing finalizers from namespace %q...\n", namespace)
+		fmt.Fprintf(k.exec.Output(), "Removing finalizers from namespace %q...\n", namespace)
 
 		k8sURL := fmt.Sprintf("http://127.0.0.1:%d/api/v1/namespaces/%s/finalize", port, namespace)
 		req, err := retryablehttp.NewRequest("PUT", k8sURL, bytes.NewReader(namespaceUpdateBytes))
 		if err != nil {
-			fmt.Println("Error creating the request to update the namespace:", err)
+			fmt.Fprintln(k.exec.Output(), "Error creating the request to update the namespace:", err)
 			return err
 		}
 		req.Header.Set("Content-Type", "application/json")
@@ -115,7 +190,8 @@ func (k Kubectl) forceNamespaceDeletion(namespace string) error {
 		return nil
 	}
 
-	err = k.exec.RunWithProxy(fun)
+	// The proxy must talk to the cluster of the kube context under test, not the one of the current context
+	err = k.exec.RunWithProxy(k.globalArgs(), fun)
 	if err != nil {
 		return fmt.Errorf("cannot force-delete namespace %q: %w", namespace, err)
 	}
@@ -125,67 +201,47 @@ func (k Kubectl) forceNamespaceDeletion(namespace string) error {
 
 	// Check again
 	_, err = k.exec.RunProcessAndCaptureOutput("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
+		k.globalArgs(),
 		"get", "namespace", namespace)
 	if err != nil {
-		fmt.Printf("Namespace %q terminated.\n", namespace)
+		fmt.Fprintf(k.exec.Output(), "Namespace %q terminated.\n", namespace)
 		return nil
 	}
 
-	fmt.Printf("Force-deleting namespace %q...\n", namespace)
+	fmt.Fprintf(k.exec.Output(), "Force-deleting namespace %q...\n", namespace)
 	err = k.exec.RunProcess("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
+		k.globalArgs(),
 		"delete", "namespace", namespace, "--force", "--grace-period=0",
 		"--ignore-not-found=true")
 	if err != nil {
-		fmt.Println("Error deleting namespace:", err)
+		fmt.Fprintln(k.exec.Output(), "Error deleting namespace:", err)
 		return err
 	}
 
 	return nil
 }
 
-func (k Kubectl) WaitForDeployments(namespace string, selector string) error {
-	output, err := k.exec.RunProcessAndCaptureStdout("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
-		"get", "deployments", "--namespace", namespace, "--selector", selector,
-		"--output", "jsonpath={.items[*].metadata.name}")
-	if err != nil {
-		return err
-	}
-
-	for deployment := range strings.FieldsSeq(output) {
-		deployment = strings.Trim(deployment, "'")
-		err = k.exec.RunProcess("kubectl",
-			fmt.Sprintf("--request-timeout=%s", k.timeout),
-			"rollout", "status", "deployment", deployment, "--namespace", namespace)
-		if err != nil {
-			return err
-		}
-
-		// 'kubectl rollout status' does not return a non-zero exit code when rollouts fail.
-		// We, thus, need to double-check here.
-		//
-		// Just after rollout, pods from the previous deployment revision may still be in a
-		// terminating state.
-		unavailable, err := k.exec.RunProcessAndCaptureStdout("kubectl",
-			fmt.Sprintf("--request-timeout=%s", k.timeout),
-			"get", "deployment", deployment, "--namespace", namespace, "--output",
-			`jsonpath={.status.unavailableReplicas}`)
+// WaitForResources waits for the Deployments, StatefulSets, DaemonSets, Jobs, PersistentVolumeClaims, and Services of
+// type LoadBalancer matching selector to become ready. The returned error names the resources which are not ready.
+func (k Kubectl) WaitForResources(namespace string, selector string) error {
+	fmt.Fprintf(k.exec.Output(), "Waiting for resources in namespace %q to become ready...\n", namespace)
+	stop := make(chan struct{})
+	defer close(stop)
+	return waitForResources(k.exec.Context(), k.exec.Output(), k.readinessTimeout, ticks(readinessPollInterval, stop), func() ([]resourceStatus, error) {
+		output, err := k.exec.RunProcessAndCaptureStdout("kubectl",
+			k.globalArgs(),
+			"get", strings.Join(readinessResources, ","), "--namespace", namespace, "--selector", selector,
+			"--output", "json")
 		if err != nil {
-			return err
-		}
-		if unavailable != "" && unavailable != "0" {
-			return fmt.Errorf("%s replicas unavailable", unavailable)
+			return nil, err
 		}
-	}
-
-	return nil
+		return parseResourceStatuses([]byte(output))
+	})
 }
 
 func (k Kubectl) GetPodsforDeployment(namespace string, deployment string) ([]string, error) {
 	jsonString, _ := k.exec.RunProcessAndCaptureStdout("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
+		k.globalArgs(),
 		"get", "deployment", deployment, "--namespace", namespace, "--output=json")
 	var deploymentMap map[string]any
 	err := json.Unmarshal([]byte(jsonString), &deploymentMap)
@@ -212,7 +268,7 @@ func (k Kubectl) GetPods(args ...string) ([]string, error) {
 	kubectlArgs = append(kubectlArgs, "get", "pods")
 	kubectlArgs = append(kubectlArgs, args...)
 	pods, err := k.exec.RunProcessAndCaptureStdout("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout), kubectlArgs)
+		k.globalArgs(), kubectlArgs)
 	if err != nil {
 		return nil, err
 	}
@@ -221,22 +277,28 @@ func (k Kubectl) GetPods(args ...string) ([]string, error) {
 
 func (k Kubectl) GetEvents(namespace string) error {
 	return k.exec.RunProcess("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
+		k.globalArgs(),
 		"get", "events", "--output", "wide", "--namespace", namespace, "--sort-by", "lastTimestamp")
 }
 
 func (k Kubectl) DescribePod(namespace string, pod string) error {
 	return k.exec.RunProcess("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
+		k.globalArgs(),
 		"describe", "pod", pod, "--namespace", namespace)
 }
 
 func (k Kubectl) Logs(namespace string, pod string, container string) error {
 	return k.exec.RunProcess("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
+		k.globalArgs(),
 		"logs", pod, "--namespace", namespace, "--container", container)
 }
 
+func (k Kubectl) PreviousLogs(namespace string, pod string, container string) error {
+	return k.exec.RunProcess("kubectl",
+		k.globalArgs(),
+		"logs", pod, "--namespace", namespace, "--container", container, "--previous")
+}
+
 func (k Kubectl) GetInitContainers(namespace string, pod string) ([]string, error) {
 	return k.GetPods(pod, "--no-headers", "--namespace", namespace, "--output", "jsonpath={.spec.initContainers[*].name}")
 }
@@ -247,12 +309,29 @@ func (k Kubectl) GetContainers(namespace string, pod string) ([]string, error) {
 
 func (k Kubectl) getNamespace(namespace string) bool {
 	_, err := k.exec.RunProcessAndCaptureOutput("kubectl",
-		fmt.Sprintf("--request-timeout=%s", k.timeout),
+		k.globalArgs(),
 		"get", "namespace", namespace)
 	if err != nil {
-		fmt.Printf("Namespace %q terminated.\n", namespace)
+		fmt.Fprintf(k.exec.Output(), "Namespace %q terminated.\n", namespace)
 		return false
 	}
 
 	return true
 }
+
+// keyValueArgs returns the entries of m as 'key=value' arguments sorted by key.
+func keyValueArgs(m map[string]string) []string {
+	args := make([]string, 0, len(m))
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		args = append(args, fmt.Sprintf("%s=%s", key, m[key]))
+	}
+	return args
+}
+
+func (k Kubectl) globalArgs() []string {
+	args := []string{fmt.Sprintf("--request-timeout=%s", k.timeout)}
+	if k.kubeContext != "" {
+		args = append(args, fmt.Sprintf("--context=%s", k.kubeContext))
+	}
+	return args
+}
@@ -14,7 +14,13 @@
 
 package tool
 
-import "github.com/helm/chart-testing/v3/pkg/exec"
+import (
+	"fmt"
+
+	"github.com/helm/chart-testing/v3/pkg/exec"
+	"github.com/helm/chart-testing/v3/pkg/yamale"
+	"github.com/helm/chart-testing/v3/pkg/yamllint"
+)
 
 type Linter struct {
 	exec exec.ProcessExecutor
@@ -33,3 +39,27 @@ func (l Linter) YamlLint(yamlFile string, configFile string) error {
 func (l Linter) Yamale(yamlFile string, schemaFile string) error {
 	return l.exec.RunProcess("yamale", "--schema", schemaFile, yamlFile)
 }
+
+// NativeLinter lints and validates YAML files with built-in implementations of yamllint and Yamale
+// instead of running their executables.
+type NativeLinter struct {
+	Linter
+}
+
+func NewNativeLinter(exec exec.ProcessExecutor) NativeLinter {
+	return NativeLinter{
+		Linter: NewLinter(exec),
+	}
+}
+
+func (l NativeLinter) YamlLint(yamlFile string, configFile string) error {
+	cfg, err := yamllint.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("%w (use '--yaml-linter=yamllint' for configurations not supported by the native linter)", err)
+	}
+	return yamllint.LintFile(l.exec.Output(), yamlFile, cfg)
+}
+
+func (l NativeLinter) Yamale(yamlFile string, schemaFile string) error {
+	return yamale.ValidateFile(l.exec.Output(), yamlFile, schemaFile)
+}
@@ -0,0 +1,289 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// readinessResources lists the kinds of resources waited for, as accepted by 'kubectl get'.
+var readinessResources = []string{
+	"deployments", "statefulsets", "daemonsets", "jobs", "persistentvolumeclaims", "services",
+}
+
+// resourceStatus describes the readiness of a single resource.
+type resourceStatus struct {
+	// Resource identifies the resource, e.g. 'deployment/foo'
+	Resource string
+	Ready    bool
+	// Failed is set if the resource will not become ready without intervention, e.g. for failed jobs
+	Failed bool
+	// Reason explains why the resource is not ready
+	Reason string
+}
+
+func (s resourceStatus) String() string {
+	return fmt.Sprintf("%s: %s", s.Resource, s.Reason)
+}
+
+// waitForResources waits until all resources returned by list are ready. The resources are checked initially and
+// whenever changed receives a value. It fails as soon as a resource has failed, if not all resources are ready
+// after timeout, or if ctx is done.
+func waitForResources(ctx context.Context, out io.Writer, timeout time.Duration, changed <-chan struct{}, list func() ([]resourceStatus, error)) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	reported := map[string]string{}
+	for {
+		statuses, err := list()
+		if err != nil {
+			return err
+		}
+
+		var notReady []string
+		for _, status := range statuses {
+			if status.Failed {
+				return fmt.Errorf("%s failed: %s", status.Resource, status.Reason)
+			}
+			if status.Ready {
+				continue
+			}
+			notReady = append(notReady, status.String())
+			if reported[status.Resource] != status.Reason {
+				fmt.Fprintf(out, "Waiting for %s\n", status)
+				reported[status.Resource] = status.Reason
+			}
+		}
+		if len(notReady) == 0 {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return fmt.Errorf("timed out after %s waiting for resources to become ready: %s", timeout, strings.Join(notReady, "; "))
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for resources to become ready: %w", context.Cause(ctx))
+		}
+	}
+}
+
+// ticks returns a channel which receives a value every interval until stop is closed.
+func ticks(interval time.Duration, stop <-chan struct{}) <-chan struct{} {
+	c := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case c <- struct{}{}:
+				case <-stop:
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// parseResourceStatuses determines the readiness of the resources in a list as printed by 'kubectl get --output json'.
+func parseResourceStatuses(data []byte) ([]resourceStatus, error) {
+	var list struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed parsing resources: %w", err)
+	}
+
+	var statuses []resourceStatus
+	for _, item := range list.Items {
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal(item, &typeMeta); err != nil {
+			return nil, fmt.Errorf("failed parsing resource: %w", err)
+		}
+
+		var object any
+		switch typeMeta.Kind {
+		case "Deployment":
+			object = &appsv1.Deployment{}
+		case "StatefulSet":
+			object = &appsv1.StatefulSet{}
+		case "DaemonSet":
+			object = &appsv1.DaemonSet{}
+		case "Job":
+			object = &batchv1.Job{}
+		case "PersistentVolumeClaim":
+			object = &corev1.PersistentVolumeClaim{}
+		case "Service":
+			object = &corev1.Service{}
+		default:
+			continue
+		}
+		if err := json.Unmarshal(item, object); err != nil {
+			return nil, fmt.Errorf("failed parsing %s: %w", typeMeta.Kind, err)
+		}
+		statuses = append(statuses, readiness(object))
+	}
+	return statuses, nil
+}
+
+// readiness returns the readiness of a Deployment, StatefulSet, DaemonSet, Job, PersistentVolumeClaim, or Service.
+func readiness(object any) resourceStatus {
+	switch o := object.(type) {
+	case *appsv1.Deployment:
+		return deploymentReadiness(o)
+	case *appsv1.StatefulSet:
+		return statefulSetReadiness(o)
+	case *appsv1.DaemonSet:
+		return daemonSetReadiness(o)
+	case *batchv1.Job:
+		return jobReadiness(o)
+	case *corev1.PersistentVolumeClaim:
+		return pvcReadiness(o)
+	case *corev1.Service:
+		return serviceReadiness(o)
+	}
+	return resourceStatus{Resource: fmt.Sprintf("%T", object), Ready: true}
+}
+
+func deploymentReadiness(d *appsv1.Deployment) resourceStatus {
+	status := resourceStatus{Resource: "deployment/" + d.Name}
+	replicas := replicasOrDefault(d.Spec.Replicas)
+
+	for _, condition := range d.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			status.Failed = true
+			status.Reason = condition.Message
+			return status
+		}
+	}
+
+	switch {
+	case d.Status.ObservedGeneration < d.Generation:
+		status.Reason = "spec update not yet observed"
+	case d.Status.UpdatedReplicas < replicas:
+		status.Reason = fmt.Sprintf("%d of %d replicas updated", d.Status.UpdatedReplicas, replicas)
+	case d.Status.AvailableReplicas < replicas:
+		status.Reason = fmt.Sprintf("%d of %d replicas available", d.Status.AvailableReplicas, replicas)
+	case d.Status.UnavailableReplicas > 0:
+		// Pods of the previous revision may still be terminating
+		status.Reason = fmt.Sprintf("%d replicas unavailable", d.Status.UnavailableReplicas)
+	default:
+		status.Ready = true
+	}
+	return status
+}
+
+func statefulSetReadiness(s *appsv1.StatefulSet) resourceStatus {
+	status := resourceStatus{Resource: "statefulset/" + s.Name}
+	replicas := replicasOrDefault(s.Spec.Replicas)
+
+	// With a partitioned rolling update only the pods with an ordinal of at least the partition are updated
+	expectedUpdated := replicas
+	rollingUpdate := s.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType
+	if rollingUpdate && s.Spec.UpdateStrategy.RollingUpdate != nil && s.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+		expectedUpdated = max(replicas-*s.Spec.UpdateStrategy.RollingUpdate.Partition, 0)
+	}
+
+	switch {
+	case s.Status.ObservedGeneration < s.Generation:
+		status.Reason = "spec update not yet observed"
+	case rollingUpdate && s.Status.UpdatedReplicas < expectedUpdated:
+		status.Reason = fmt.Sprintf("%d of %d replicas updated", s.Status.UpdatedReplicas, expectedUpdated)
+	case s.Status.ReadyReplicas < replicas:
+		status.Reason = fmt.Sprintf("%d of %d replicas ready", s.Status.ReadyReplicas, replicas)
+	default:
+		status.Ready = true
+	}
+	return status
+}
+
+func daemonSetReadiness(d *appsv1.DaemonSet) resourceStatus {
+	status := resourceStatus{Resource: "daemonset/" + d.Name}
+	desired := d.Status.DesiredNumberScheduled
+
+	switch {
+	case d.Status.ObservedGeneration < d.Generation:
+		status.Reason = "spec update not yet observed"
+	case d.Spec.UpdateStrategy.Type != appsv1.OnDeleteDaemonSetStrategyType && d.Status.UpdatedNumberScheduled < desired:
+		status.Reason = fmt.Sprintf("%d of %d pods updated", d.Status.UpdatedNumberScheduled, desired)
+	case d.Status.NumberAvailable < desired:
+		status.Reason = fmt.Sprintf("%d of %d pods available", d.Status.NumberAvailable, desired)
+	default:
+		status.Ready = true
+	}
+	return status
+}
+
+func jobReadiness(j *batchv1.Job) resourceStatus {
+	status := resourceStatus{Resource: "job/" + j.Name, Reason: "not completed"}
+	for _, condition := range j.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			status.Ready = true
+			status.Reason = ""
+		case batchv1.JobFailed:
+			status.Failed = true
+			status.Reason = condition.Message
+			if status.Reason == "" {
+				status.Reason = condition.Reason
+			}
+		}
+	}
+	return status
+}
+
+func pvcReadiness(p *corev1.PersistentVolumeClaim) resourceStatus {
+	status := resourceStatus{Resource: "persistentvolumeclaim/" + p.Name}
+	if p.Status.Phase == corev1.ClaimBound {
+		status.Ready = true
+	} else {
+		status.Reason = fmt.Sprintf("phase is %q", p.Status.Phase)
+	}
+	return status
+}
+
+func serviceReadiness(s *corev1.Service) resourceStatus {
+	status := resourceStatus{Resource: "service/" + s.Name, Ready: true}
+	if s.Spec.Type == corev1.ServiceTypeLoadBalancer && len(s.Status.LoadBalancer.Ingress) == 0 {
+		status.Ready = false
+		status.Reason = "load balancer has no ingress"
+	}
+	return status
+}
+
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
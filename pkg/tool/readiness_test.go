@@ -0,0 +1,172 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResourceStatuses(t *testing.T) {
+	var testDataSet = []struct {
+		name     string
+		item     string
+		expected resourceStatus
+	}{
+		{
+			"ready deployment",
+			`{"kind": "Deployment", "metadata": {"name": "web", "generation": 2}, "spec": {"replicas": 2},
+			  "status": {"observedGeneration": 2, "updatedReplicas": 2, "availableReplicas": 2}}`,
+			resourceStatus{Resource: "deployment/web", Ready: true},
+		},
+		{
+			"deployment with unavailable replicas",
+			`{"kind": "Deployment", "metadata": {"name": "web"},
+			  "status": {"updatedReplicas": 1, "availableReplicas": 0}}`,
+			resourceStatus{Resource: "deployment/web", Reason: "0 of 1 replicas available"},
+		},
+		{
+			"deployment exceeding its progress deadline",
+			`{"kind": "Deployment", "metadata": {"name": "web"}, "status": {"conditions": [{"type": "Progressing",
+			  "status": "False", "reason": "ProgressDeadlineExceeded", "message": "ReplicaSet web-1 has timed out progressing."}]}}`,
+			resourceStatus{Resource: "deployment/web", Failed: true, Reason: "ReplicaSet web-1 has timed out progressing."},
+		},
+		{
+			"statefulset with unobserved spec",
+			`{"kind": "StatefulSet", "metadata": {"name": "db", "generation": 3}, "status": {"observedGeneration": 2}}`,
+			resourceStatus{Resource: "statefulset/db", Reason: "spec update not yet observed"},
+		},
+		{
+			"statefulset waiting for ready replicas",
+			`{"kind": "StatefulSet", "metadata": {"name": "db"}, "spec": {"replicas": 3},
+			  "status": {"updatedReplicas": 3, "readyReplicas": 1}}`,
+			resourceStatus{Resource: "statefulset/db", Reason: "1 of 3 replicas ready"},
+		},
+		{
+			"partitioned statefulset",
+			`{"kind": "StatefulSet", "metadata": {"name": "db"}, "spec": {"replicas": 3,
+			  "updateStrategy": {"type": "RollingUpdate", "rollingUpdate": {"partition": 2}}},
+			  "status": {"updatedReplicas": 1, "readyReplicas": 3}}`,
+			resourceStatus{Resource: "statefulset/db", Ready: true},
+		},
+		{
+			"daemonset waiting for updated pods",
+			`{"kind": "DaemonSet", "metadata": {"name": "agent"},
+			  "status": {"desiredNumberScheduled": 3, "updatedNumberScheduled": 2, "numberAvailable": 3}}`,
+			resourceStatus{Resource: "daemonset/agent", Reason: "2 of 3 pods updated"},
+		},
+		{
+			"daemonset with on delete update strategy",
+			`{"kind": "DaemonSet", "metadata": {"name": "agent"}, "spec": {"updateStrategy": {"type": "OnDelete"}},
+			  "status": {"desiredNumberScheduled": 3, "updatedNumberScheduled": 0, "numberAvailable": 3}}`,
+			resourceStatus{Resource: "daemonset/agent", Ready: true},
+		},
+		{
+			"running job",
+			`{"kind": "Job", "metadata": {"name": "migrate"}, "status": {"active": 1}}`,
+			resourceStatus{Resource: "job/migrate", Reason: "not completed"},
+		},
+		{
+			"completed job",
+			`{"kind": "Job", "metadata": {"name": "migrate"}, "status": {"conditions": [{"type": "Complete", "status": "True"}]}}`,
+			resourceStatus{Resource: "job/migrate", Ready: true},
+		},
+		{
+			"failed job",
+			`{"kind": "Job", "metadata": {"name": "migrate"}, "status": {"conditions": [{"type": "Failed", "status": "True",
+			  "reason": "BackoffLimitExceeded", "message": "Job has reached the specified backoff limit"}]}}`,
+			resourceStatus{Resource: "job/migrate", Failed: true, Reason: "Job has reached the specified backoff limit"},
+		},
+		{
+			"pending pvc",
+			`{"kind": "PersistentVolumeClaim", "metadata": {"name": "data"}, "status": {"phase": "Pending"}}`,
+			resourceStatus{Resource: "persistentvolumeclaim/data", Reason: `phase is "Pending"`},
+		},
+		{
+			"bound pvc",
+			`{"kind": "PersistentVolumeClaim", "metadata": {"name": "data"}, "status": {"phase": "Bound"}}`,
+			resourceStatus{Resource: "persistentvolumeclaim/data", Ready: true},
+		},
+		{
+			"cluster ip service",
+			`{"kind": "Service", "metadata": {"name": "web"}, "spec": {"type": "ClusterIP"}}`,
+			resourceStatus{Resource: "service/web", Ready: true},
+		},
+		{
+			"load balancer without ingress",
+			`{"kind": "Service", "metadata": {"name": "web"}, "spec": {"type": "LoadBalancer"}}`,
+			resourceStatus{Resource: "service/web", Reason: "load balancer has no ingress"},
+		},
+		{
+			"load balancer with ingress",
+			`{"kind": "Service", "metadata": {"name": "web"}, "spec": {"type": "LoadBalancer"},
+			  "status": {"loadBalancer": {"ingress": [{"ip": "10.0.0.1"}]}}}`,
+			resourceStatus{Resource: "service/web", Ready: true},
+		},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			statuses, err := parseResourceStatuses([]byte(`{"items": [` + testData.item + `]}`))
+			require.NoError(t, err)
+			assert.Equal(t, []resourceStatus{testData.expected}, statuses)
+		})
+	}
+}
+
+func TestWaitForResources(t *testing.T) {
+	t.Run("waits until all resources are ready", func(t *testing.T) {
+		var b strings.Builder
+		changed := make(chan struct{}, 2)
+		changed <- struct{}{}
+		changed <- struct{}{}
+		polls := 0
+		err := waitForResources(context.Background(), &b, time.Minute, changed, func() ([]resourceStatus, error) {
+			polls++
+			return []resourceStatus{
+				{Resource: "deployment/web", Ready: true},
+				{Resource: "job/migrate", Ready: polls == 3, Reason: "not completed"},
+			}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, polls)
+		assert.Equal(t, "Waiting for job/migrate: not completed\n", b.String())
+	})
+
+	t.Run("fails immediately for failed resources", func(t *testing.T) {
+		var b strings.Builder
+		err := waitForResources(context.Background(), &b, time.Minute, nil, func() ([]resourceStatus, error) {
+			return []resourceStatus{{Resource: "job/migrate", Failed: true, Reason: "BackoffLimitExceeded"}}, nil
+		})
+		assert.EqualError(t, err, "job/migrate failed: BackoffLimitExceeded")
+	})
+
+	t.Run("reports resources which are not ready after the timeout", func(t *testing.T) {
+		var b strings.Builder
+		err := waitForResources(context.Background(), &b, 0, nil, func() ([]resourceStatus, error) {
+			return []resourceStatus{
+				{Resource: "deployment/web", Reason: "0 of 1 replicas available"},
+				{Resource: "service/web", Reason: "load balancer has no ingress"},
+			}, nil
+		})
+		assert.EqualError(t, err, "timed out after 0s waiting for resources to become ready: "+
+			"deployment/web: 0 of 1 replicas available; service/web: load balancer has no ingress")
+	})
+}
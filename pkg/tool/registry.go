@@ -0,0 +1,38 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// ListOCITags returns the tags of the chart with the specified OCI reference (e.g. 'oci://ghcr.io/org/charts/foo')
+// which are valid SemVer versions, using the credentials of 'helm registry login'.
+func ListOCITags(ref string) ([]string, error) {
+	registryClient, err := registry.NewClient(registry.ClientOptCredentialsFile(cli.New().RegistryConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed creating registry client: %w", err)
+	}
+
+	tags, err := registryClient.Tags(strings.TrimPrefix(ref, fmt.Sprintf("%s://", registry.OCIScheme)))
+	if err != nil {
+		return nil, fmt.Errorf("failed listing tags of %s: %w", ref, err)
+	}
+	return tags, nil
+}
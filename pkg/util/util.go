@@ -26,7 +26,7 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/Masterminds/semver"
+	"github.com/Masterminds/semver/v3"
 	"github.com/hashicorp/go-multierror"
 	"gopkg.in/yaml.v2"
 )
@@ -38,11 +38,19 @@ type Maintainer struct {
 	Email string `yaml:"email"`
 }
 
+type Dependency struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version"`
+	Repository string `yaml:"repository"`
+}
+
 type ChartYaml struct {
-	Name        string `yaml:"name"`
-	Version     string `yaml:"version"`
-	Deprecated  bool   `yaml:"deprecated"`
-	Maintainers []Maintainer
+	Name         string `yaml:"name"`
+	Version      string `yaml:"version"`
+	Deprecated   bool   `yaml:"deprecated"`
+	KubeVersion  string `yaml:"kubeVersion"`
+	Maintainers  []Maintainer
+	Dependencies []Dependency `yaml:"dependencies"`
 }
 
 func Flatten(items []any) ([]string, error) {
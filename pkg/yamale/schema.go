@@ -0,0 +1,379 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yamale validates YAML documents against schemas written in the syntax of Yamale
+// (https://github.com/23andMe/Yamale).
+package yamale
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is a parsed Yamale schema. The first document of a schema file describes the data, the following documents
+// define the schemas which can be referenced with include().
+type Schema struct {
+	root     *validator
+	includes map[string]*validator
+}
+
+// validator is a node of a schema. It is either a call of a validator function such as str() or list(), or a map
+// describing the fields of a map.
+type validator struct {
+	function string
+	args     []*validator
+	literals []any
+
+	required bool
+	min      *float64
+	max      *float64
+	key      *validator
+	include  string
+	pattern  *regexp.Regexp
+
+	// fields and fieldOrder describe the fields of a map for validators with function 'fields'
+	fields     map[string]*validator
+	fieldOrder []string
+}
+
+// LoadSchema reads a Yamale schema file.
+func LoadSchema(schemaFile string) (*Schema, error) {
+	data, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading schema: %w", err)
+	}
+	schema, err := ParseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", schemaFile, err)
+	}
+	return schema, nil
+}
+
+// ParseSchema parses a Yamale schema.
+func ParseSchema(data []byte) (*Schema, error) {
+	schema := &Schema{includes: map[string]*validator{}}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for i := 0; ; i++ {
+		var document yaml.Node
+		if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("document %d is not a map", i+1)
+		}
+
+		if i == 0 {
+			root, err := parseFields(document.Content[0])
+			if err != nil {
+				return nil, err
+			}
+			schema.root = root
+			continue
+		}
+
+		content := document.Content[0].Content
+		for j := 0; j+1 < len(content); j += 2 {
+			name := content[j].Value
+			v, err := parseNode(content[j+1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			schema.includes[name] = v
+		}
+	}
+
+	if schema.root == nil {
+		return nil, errors.New("schema is empty")
+	}
+	if err := schema.checkIncludes(schema.root); err != nil {
+		return nil, err
+	}
+	for _, v := range schema.includes {
+		if err := schema.checkIncludes(v); err != nil {
+			return nil, err
+		}
+	}
+	return schema, nil
+}
+
+func (s *Schema) checkIncludes(v *validator) error {
+	if v.function == "include" {
+		if _, ok := s.includes[v.include]; !ok {
+			return fmt.Errorf("include %q is not defined", v.include)
+		}
+	}
+	children := append([]*validator{}, v.args...)
+	if v.key != nil {
+		children = append(children, v.key)
+	}
+	for _, field := range v.fields {
+		children = append(children, field)
+	}
+	for _, child := range children {
+		if err := s.checkIncludes(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseNode(node *yaml.Node) (*validator, error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return parseFields(node)
+	case yaml.ScalarNode:
+		return parseExpression(node.Value)
+	default:
+		return nil, fmt.Errorf("line %d: expected a validator or a map", node.Line)
+	}
+}
+
+func parseFields(node *yaml.Node) (*validator, error) {
+	v := &validator{function: "fields", required: true, fields: map[string]*validator{}}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		field, err := parseNode(node.Content[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		v.fields[name] = field
+		v.fieldOrder = append(v.fieldOrder, name)
+	}
+	return v, nil
+}
+
+// expressionParser parses validator expressions such as "list(str(), required=False)".
+type expressionParser struct {
+	input string
+	pos   int
+}
+
+func parseExpression(expression string) (*validator, error) {
+	p := &expressionParser{input: expression}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, fmt.Errorf("invalid validator %q: %w", expression, err)
+	}
+	p.skipSpaces()
+	v, ok := value.(*validator)
+	if !ok || p.pos != len(p.input) {
+		return nil, fmt.Errorf("invalid validator %q", expression)
+	}
+	return v, nil
+}
+
+func (p *expressionParser) parseValue() (any, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	switch c := p.input[p.pos]; {
+	case c == '\'' || c == '"':
+		end := strings.IndexByte(p.input[p.pos+1:], c)
+		if end < 0 {
+			return nil, errors.New("unterminated string")
+		}
+		value := p.input[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value, nil
+	case c == '-' || c == '.' || unicode.IsDigit(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) && strings.ContainsRune("-+.eE0123456789", rune(p.input[p.pos])) {
+			p.pos++
+		}
+		return strconv.ParseFloat(p.input[start:p.pos], 64)
+	}
+
+	name := p.parseIdentifier()
+	switch name {
+	case "":
+		return nil, fmt.Errorf("unexpected character %q", p.input[p.pos])
+	case "True":
+		return true, nil
+	case "False":
+		return false, nil
+	case "None":
+		return nil, nil
+	}
+
+	p.skipSpaces()
+	if p.pos >= len(p.input) || p.input[p.pos] != '(' {
+		return nil, fmt.Errorf("expected '(' after %q", name)
+	}
+	p.pos++
+	return p.parseCall(name)
+}
+
+func (p *expressionParser) parseCall(function string) (*validator, error) {
+	if !isKnownFunction(function) {
+		return nil, fmt.Errorf("unknown validator %q", function)
+	}
+	v := &validator{function: function, required: true}
+
+	for {
+		p.skipSpaces()
+		if p.pos < len(p.input) && p.input[p.pos] == ')' {
+			p.pos++
+			break
+		}
+
+		// Keyword arguments are introduced by an identifier followed by '='
+		start := p.pos
+		keyword := p.parseIdentifier()
+		p.skipSpaces()
+		if keyword != "" && p.pos < len(p.input) && p.input[p.pos] == '=' {
+			p.pos++
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if err := v.setKeyword(keyword, value); err != nil {
+				return nil, err
+			}
+		} else {
+			p.pos = start
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if arg, ok := value.(*validator); ok {
+				v.args = append(v.args, arg)
+			} else {
+				v.literals = append(v.literals, value)
+			}
+		}
+
+		p.skipSpaces()
+		if p.pos < len(p.input) && p.input[p.pos] == ',' {
+			p.pos++
+		} else if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return nil, fmt.Errorf("expected ',' or ')' in arguments of %s()", function)
+		}
+	}
+
+	return v, v.checkArguments()
+}
+
+func (p *expressionParser) parseIdentifier() string {
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := rune(p.input[p.pos])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' {
+			break
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *expressionParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func isKnownFunction(function string) bool {
+	switch function {
+	case "str", "num", "int", "bool", "null", "any", "list", "map", "include", "enum", "regex":
+		return true
+	}
+	return false
+}
+
+func (v *validator) setKeyword(keyword string, value any) error {
+	switch keyword {
+	case "required":
+		required, ok := value.(bool)
+		if !ok {
+			return errors.New("'required' must be True or False")
+		}
+		v.required = required
+	case "min", "max":
+		limit, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("'%s' must be a number", keyword)
+		}
+		if keyword == "min" {
+			v.min = &limit
+		} else {
+			v.max = &limit
+		}
+	case "key":
+		key, ok := value.(*validator)
+		if !ok || v.function != "map" {
+			return errors.New("'key' must be a validator and is only supported by map()")
+		}
+		v.key = key
+	default:
+		return fmt.Errorf("unsupported keyword argument %q", keyword)
+	}
+	return nil
+}
+
+func (v *validator) checkArguments() error {
+	switch v.function {
+	case "include":
+		if len(v.literals) != 1 || len(v.args) != 0 {
+			return errors.New("include() requires the name of a schema")
+		}
+		name, ok := v.literals[0].(string)
+		if !ok {
+			return errors.New("include() requires the name of a schema")
+		}
+		v.include = name
+	case "regex":
+		if len(v.literals) == 0 || len(v.args) != 0 {
+			return errors.New("regex() requires at least one pattern")
+		}
+		var patterns []string
+		for _, literal := range v.literals {
+			pattern, ok := literal.(string)
+			if !ok {
+				return errors.New("regex() patterns must be strings")
+			}
+			patterns = append(patterns, "(?:"+pattern+")")
+		}
+		pattern, err := regexp.Compile("^(?:" + strings.Join(patterns, "|") + ")")
+		if err != nil {
+			return fmt.Errorf("invalid pattern in regex(): %w", err)
+		}
+		v.pattern = pattern
+	case "enum":
+		if len(v.literals) == 0 || len(v.args) != 0 {
+			return errors.New("enum() requires at least one value")
+		}
+	case "any", "list", "map":
+		if len(v.literals) != 0 {
+			return fmt.Errorf("%s() only accepts validators as arguments", v.function)
+		}
+	default:
+		if len(v.literals) != 0 || len(v.args) != 0 {
+			return fmt.Errorf("%s() does not accept positional arguments", v.function)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamale
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateFile validates dataFile against the Yamale schema in schemaFile and prints the result to w the way Yamale
+// does. All violations are printed, an error is returned if there is at least one.
+func ValidateFile(w io.Writer, dataFile string, schemaFile string) error {
+	schema, err := LoadSchema(schemaFile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(dataFile)
+	if err != nil {
+		return fmt.Errorf("failed reading %s: %w", dataFile, err)
+	}
+
+	fmt.Fprintf(w, "Validating %s...\n", dataFile)
+	violations, err := schema.Validate(data)
+	if err != nil {
+		return fmt.Errorf("failed parsing %s: %w", dataFile, err)
+	}
+	if len(violations) == 0 {
+		fmt.Fprintln(w, "Validation success! 👍")
+		return nil
+	}
+
+	fmt.Fprintln(w, "Validation failed!")
+	fmt.Fprintf(w, "Error validating data '%s' with schema '%s'\n", dataFile, schemaFile)
+	for _, violation := range violations {
+		fmt.Fprintf(w, "\t%s\n", violation)
+	}
+	return fmt.Errorf("%s does not match schema %s: found %d violation(s)", dataFile, schemaFile, len(violations))
+}
+
+// Validate validates every document in data against the schema. It returns all violations, each prefixed with the
+// path of the offending element, e.g. "maintainers.0.name: Required field missing".
+func (s *Schema) Validate(data []byte) ([]string, error) {
+	var violations []string
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		root := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+		if len(document.Content) > 0 {
+			root = document.Content[0]
+		}
+		violations = append(violations, s.validate(s.root, "", root)...)
+	}
+
+	return violations, nil
+}
+
+func (s *Schema) validate(v *validator, path string, node *yaml.Node) []string {
+	node = resolveAlias(node)
+
+	switch v.function {
+	case "fields":
+		return s.validateFields(v, path, node)
+	case "include":
+		return s.validate(s.includes[v.include], path, node)
+	case "any":
+		if len(v.args) == 0 || s.matchesAny(v.args, path, node) {
+			return nil
+		}
+		var names []string
+		for _, arg := range v.args {
+			if !slices.Contains(names, arg.name()) {
+				names = append(names, arg.name())
+			}
+		}
+		return violation(path, "'%s' is not a %s.", describe(node), strings.Join(names, " or "))
+	case "list":
+		if node.Kind != yaml.SequenceNode {
+			return violation(path, "'%s' is not a list.", describe(node))
+		}
+		violations := checkLength(v, path, node, len(node.Content))
+		for i, item := range node.Content {
+			violations = append(violations, s.validateItem(v.args, joinPath(path, strconv.Itoa(i)), item)...)
+		}
+		return violations
+	case "map":
+		if node.Kind != yaml.MappingNode {
+			return violation(path, "'%s' is not a map.", describe(node))
+		}
+		violations := checkLength(v, path, node, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			itemPath := joinPath(path, key.Value)
+			if v.key != nil {
+				for _, keyViolation := range s.validate(v.key, itemPath, key) {
+					violations = append(violations, "Key error - "+keyViolation)
+				}
+			}
+			violations = append(violations, s.validateItem(v.args, itemPath, value)...)
+		}
+		return violations
+	}
+
+	return validateScalar(v, path, node)
+}
+
+func (s *Schema) validateFields(v *validator, path string, node *yaml.Node) []string {
+	if node.Kind != yaml.MappingNode {
+		return violation(path, "'%s' is not a map.", describe(node))
+	}
+
+	values := map[string]*yaml.Node{}
+	var violations []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		values[key] = node.Content[i+1]
+		if _, ok := v.fields[key]; !ok {
+			violations = append(violations, violation(joinPath(path, key), "Unexpected element")...)
+		}
+	}
+
+	for _, name := range v.fieldOrder {
+		field := v.fields[name]
+		value, ok := values[name]
+		if !ok || isNull(value) && field.function != "null" {
+			if field.required {
+				violations = append(violations, violation(joinPath(path, name), "Required field missing")...)
+			}
+			continue
+		}
+		violations = append(violations, s.validate(field, joinPath(path, name), value)...)
+	}
+
+	// Report violations in document order rather than schema order
+	return sortByPath(violations, node, path)
+}
+
+// validateItem validates an element of a list or a value of a map. With a single validator its violations are
+// reported directly, with multiple validators the element must match one of them.
+func (s *Schema) validateItem(validators []*validator, path string, node *yaml.Node) []string {
+	switch len(validators) {
+	case 0:
+		return nil
+	case 1:
+		return s.validate(validators[0], path, node)
+	}
+	return s.validate(&validator{function: "any", args: validators}, path, node)
+}
+
+func (s *Schema) matchesAny(validators []*validator, path string, node *yaml.Node) bool {
+	for _, v := range validators {
+		if len(s.validate(v, path, node)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func validateScalar(v *validator, path string, node *yaml.Node) []string {
+	if !v.matchesType(node) {
+		return violation(path, "'%s' is not a %s.", describe(node), v.name())
+	}
+
+	switch v.function {
+	case "str":
+		return checkLength(v, path, node, len([]rune(node.Value)))
+	case "num", "int":
+		value, _ := strconv.ParseFloat(node.Value, 64)
+		if v.min != nil && value < *v.min {
+			return violation(path, "%s is less than %s", node.Value, formatNumber(*v.min))
+		}
+		if v.max != nil && value > *v.max {
+			return violation(path, "%s is greater than %s", node.Value, formatNumber(*v.max))
+		}
+	case "enum":
+		for _, literal := range v.literals {
+			if matchesLiteral(node, literal) {
+				return nil
+			}
+		}
+		var values []string
+		for _, literal := range v.literals {
+			values = append(values, fmt.Sprintf("'%v'", literal))
+		}
+		return violation(path, "'%s' not in (%s)", describe(node), strings.Join(values, ", "))
+	case "regex":
+		if !v.pattern.MatchString(node.Value) {
+			return violation(path, "'%s' is not a regex match.", describe(node))
+		}
+	}
+	return nil
+}
+
+func (v *validator) matchesType(node *yaml.Node) bool {
+	switch v.function {
+	case "str", "regex":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!str"
+	case "num":
+		return node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!float")
+	case "int":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!int"
+	case "bool":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!bool"
+	case "null":
+		return isNull(node)
+	}
+	return node.Kind == yaml.ScalarNode
+}
+
+// name returns the name of the validator as used in violation messages.
+func (v *validator) name() string {
+	switch v.function {
+	case "include":
+		return v.include
+	case "fields":
+		return "map"
+	}
+	return v.function
+}
+
+func checkLength(v *validator, path string, node *yaml.Node, length int) []string {
+	if v.min != nil && float64(length) < *v.min {
+		return violation(path, "Length of '%s' is less than %s", describe(node), formatNumber(*v.min))
+	}
+	if v.max != nil && float64(length) > *v.max {
+		return violation(path, "Length of '%s' is greater than %s", describe(node), formatNumber(*v.max))
+	}
+	return nil
+}
+
+func matchesLiteral(node *yaml.Node, literal any) bool {
+	switch literal := literal.(type) {
+	case string:
+		return node.Tag == "!!str" && node.Value == literal
+	case float64:
+		value, err := strconv.ParseFloat(node.Value, 64)
+		return (node.Tag == "!!int" || node.Tag == "!!float") && err == nil && value == literal
+	case bool:
+		value, err := strconv.ParseBool(node.Value)
+		return node.Tag == "!!bool" && err == nil && value == literal
+	case nil:
+		return isNull(node)
+	}
+	return false
+}
+
+// sortByPath orders violations of the fields of node by the position of the fields in the document.
+func sortByPath(violations []string, node *yaml.Node, path string) []string {
+	var sorted []string
+	used := make([]bool, len(violations))
+	for i := 0; i < len(node.Content); i += 2 {
+		prefix := joinPath(path, node.Content[i].Value)
+		for j, violation := range violations {
+			if !used[j] && (strings.HasPrefix(violation, prefix+": ") || strings.HasPrefix(violation, prefix+".")) {
+				sorted = append(sorted, violation)
+				used[j] = true
+			}
+		}
+	}
+	for j, violation := range violations {
+		if !used[j] {
+			sorted = append(sorted, violation)
+		}
+	}
+	return sorted
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "{...}"
+	case yaml.SequenceNode:
+		return "[...]"
+	}
+	return node.Value
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func joinPath(path string, element string) string {
+	if path == "" {
+		return element
+	}
+	return path + "." + element
+}
+
+func violation(path string, format string, args ...any) []string {
+	message := fmt.Sprintf(format, args...)
+	if path == "" {
+		return []string{message}
+	}
+	return []string{path + ": " + message}
+}
@@ -0,0 +1,186 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamale
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateChartSchema(t *testing.T) {
+	schema, err := LoadSchema("../../etc/chart_schema.yaml")
+	require.NoError(t, err)
+
+	var testDataSet = []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			"valid",
+			heredoc.Doc(`
+				apiVersion: v2
+				name: foo
+				version: 1.0.0
+				appVersion: 1.2
+				annotations:
+				  category: Database
+				maintainers:
+				  - name: alice
+				    email: alice@example.com
+				dependencies:
+				  - name: common
+				    version: 1.x.x
+				    repository: file://../common
+				    import-values:
+				      - data
+				      - child: default.data
+				        parent: myimports
+				`),
+			nil,
+		},
+		{
+			"optional fields set to null",
+			"apiVersion: v2\nname: foo\nversion: 1.0.0\nhome:\n",
+			nil,
+		},
+		{
+			"every violation is reported",
+			heredoc.Doc(`
+				apiVersion: v2
+				name: foo
+				version: 1.0
+				deprecated: "no"
+				keywords: [a, 1]
+				maintainers:
+				  - email: bob@example.com
+				    github: bob
+				dependencies:
+				  - name: common
+				    version: 1.x.x
+				    import-values:
+				      - child: data
+				annotations:
+				  revision: 2
+				unknown: true
+				`),
+			[]string{
+				"version: '1.0' is not a str.",
+				"deprecated: 'no' is not a bool.",
+				"keywords.1: '1' is not a str.",
+				"maintainers.0.github: Unexpected element",
+				"maintainers.0.name: Required field missing",
+				"dependencies.0.import-values.0: '{...}' is not a str or import-value.",
+				"annotations.revision: '2' is not a str.",
+				"unknown: Unexpected element",
+			},
+		},
+		{
+			"missing required fields",
+			"description: foo\n",
+			[]string{"name: Required field missing", "version: Required field missing", "apiVersion: Required field missing"},
+		},
+		{
+			"not a map",
+			"- foo\n",
+			[]string{"'[...]' is not a map."},
+		},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			violations, err := schema.Validate([]byte(testData.data))
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, violations)
+		})
+	}
+}
+
+func TestValidators(t *testing.T) {
+	var testDataSet = []struct {
+		name     string
+		schema   string
+		data     string
+		expected []string
+	}{
+		{"num", "a: num()\nb: num()\nc: num()\n", "a: 1\nb: 1.5\nc: '1'\n", []string{"c: '1' is not a num."}},
+		{"int", "a: int(min=1, max=3)\nb: int(min=1)\nc: int()\n", "a: 4\nb: 0\nc: 1.5\n", []string{"a: 4 is greater than 3", "b: 0 is less than 1", "c: '1.5' is not a int."}},
+		{"str length", "a: str(min=2, max=3)\n", "a: abcd\n", []string{"a: Length of 'abcd' is greater than 3"}},
+		{"enum", "a: enum('x', 'y')\nb: enum('x', 1)\n", "a: z\nb: 1\n", []string{"a: 'z' not in ('x', 'y')"}},
+		{"regex", "a: regex('^v[0-9]+$')\n", "a: version1\n", []string{"a: 'version1' is not a regex match."}},
+		{"any without arguments", "a: any()\n", "a: [1, 2]\n", nil},
+		{"list length", "a: list(str(), min=2)\n", "a: [x]\n", []string{"a: Length of '[...]' is less than 2"}},
+		{"map keys", "a: map(int(), key=regex('^[a-z]+$'))\n", "a: {x: 1, Y: 2}\n", []string{"Key error - a.Y: 'Y' is not a regex match."}},
+		{"nested map", "a:\n  b: str()\n", "a:\n  c: x\n", []string{"a.c: Unexpected element", "a.b: Required field missing"}},
+		{"required nested map", "a:\n  b: str()\n", "c: 1\n", []string{"c: Unexpected element", "a: Required field missing"}},
+		{"aliases", "a: str()\nb: str()\n", "a: &x foo\nb: *x\n", nil},
+		{"multiple documents", "a: str()\n", "a: x\n---\na: 1\n", []string{"a: '1' is not a str."}},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			schema, err := ParseSchema([]byte(testData.schema))
+			require.NoError(t, err)
+			violations, err := schema.Validate([]byte(testData.data))
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, violations)
+		})
+	}
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	var testDataSet = []struct {
+		schema        string
+		expectedError string
+	}{
+		{"a: foo()\n", `unknown validator "foo"`},
+		{"a: str(\n", "unexpected end of expression"},
+		{"a: str(required=1)\n", "'required' must be True or False"},
+		{"a: str(size=1)\n", `unsupported keyword argument "size"`},
+		{"a: include('missing')\n", `include "missing" is not defined`},
+		{"a: str() str()\n", `invalid validator "str() str()"`},
+		{"- a\n", "document 1 is not a map"},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.schema, func(t *testing.T) {
+			_, err := ParseSchema([]byte(testData.schema))
+			assert.ErrorContains(t, err, testData.expectedError)
+		})
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "schema.yaml")
+	require.NoError(t, os.WriteFile(schemaFile, []byte("name: str()\n"), 0644))
+	dataFile := filepath.Join(dir, "Chart.yaml")
+	require.NoError(t, os.WriteFile(dataFile, []byte("name: 1\nversion: 1.0.0\n"), 0644))
+
+	var b strings.Builder
+	err := ValidateFile(&b, dataFile, schemaFile)
+	assert.EqualError(t, err, dataFile+" does not match schema "+schemaFile+": found 2 violation(s)")
+	assert.Equal(t, "Validating "+dataFile+"...\n"+
+		"Validation failed!\n"+
+		"Error validating data '"+dataFile+"' with schema '"+schemaFile+"'\n"+
+		"\tname: '1' is not a str.\n"+
+		"\tversion: Unexpected element\n", b.String())
+}
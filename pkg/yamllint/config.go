@@ -0,0 +1,191 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamllint
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// ruleDefaults holds the supported rules with the default values of their options as documented by yamllint.
+var ruleDefaults = map[string]map[string]any{
+	"braces": {
+		"forbid":                  false,
+		"min-spaces-inside":       0,
+		"max-spaces-inside":       0,
+		"min-spaces-inside-empty": -1,
+		"max-spaces-inside-empty": -1,
+	},
+	"brackets": {
+		"forbid":                  false,
+		"min-spaces-inside":       0,
+		"max-spaces-inside":       0,
+		"min-spaces-inside-empty": -1,
+		"max-spaces-inside-empty": -1,
+	},
+	"colons": {
+		"max-spaces-before": 0,
+		"max-spaces-after":  1,
+	},
+	"commas": {
+		"max-spaces-before": 0,
+		"min-spaces-after":  1,
+		"max-spaces-after":  1,
+	},
+	"comments": {
+		"require-starting-space":  true,
+		"ignore-shebangs":         true,
+		"min-spaces-from-content": 2,
+	},
+	"document-start": {
+		"present": true,
+	},
+	"empty-lines": {
+		"max":       2,
+		"max-start": 0,
+		"max-end":   0,
+	},
+	"hyphens": {
+		"max-spaces-after": 1,
+	},
+	"indentation": {
+		"spaces":                   "consistent",
+		"indent-sequences":         true,
+		"check-multi-line-strings": false,
+	},
+	"key-duplicates": {},
+	"line-length": {
+		"max":                                 80,
+		"allow-non-breakable-words":           true,
+		"allow-non-breakable-inline-mappings": false,
+	},
+	"new-line-at-end-of-file": {},
+	"new-lines": {
+		"type": "unix",
+	},
+	"trailing-spaces": {},
+	"truthy": {
+		"allowed-values": []any{"true", "false"},
+		"check-keys":     true,
+	},
+}
+
+// Config holds the enabled rules of a yamllint configuration.
+type Config struct {
+	rules map[string]*ruleConfig
+}
+
+type ruleConfig struct {
+	level   string
+	options map[string]any
+}
+
+// LoadConfig reads a yamllint configuration file.
+func LoadConfig(configFile string) (*Config, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading yamllint config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses a yamllint configuration. Only rules listed in the configuration are enabled. Extending other
+// configurations is not supported.
+func ParseConfig(data []byte) (*Config, error) {
+	var raw struct {
+		Extends string         `yaml:"extends"`
+		Rules   map[string]any `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid yamllint config: %w", err)
+	}
+	if raw.Extends != "" {
+		return nil, fmt.Errorf("invalid yamllint config: 'extends' is not supported")
+	}
+
+	cfg := &Config{rules: map[string]*ruleConfig{}}
+	for name, value := range raw.Rules {
+		defaults, ok := ruleDefaults[name]
+
+		var options map[string]any
+		switch v := value.(type) {
+		case string:
+			if v == "disable" {
+				continue
+			} else if v != "enable" {
+				return nil, fmt.Errorf("invalid yamllint config: rule %q must be 'enable', 'disable', or a map of options", name)
+			}
+		case map[string]any:
+			options = v
+		default:
+			return nil, fmt.Errorf("invalid yamllint config: rule %q must be 'enable', 'disable', or a map of options", name)
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid yamllint config: rule %q is not supported", name)
+		}
+
+		rule := &ruleConfig{level: LevelError, options: map[string]any{}}
+		for option, defaultValue := range defaults {
+			rule.options[option] = defaultValue
+		}
+		for option, optionValue := range options {
+			if option == "level" {
+				level, _ := optionValue.(string)
+				if level != LevelError && level != LevelWarning {
+					return nil, fmt.Errorf("invalid yamllint config: level of rule %q must be 'error' or 'warning'", name)
+				}
+				rule.level = level
+				continue
+			}
+			if _, ok := defaults[option]; !ok {
+				return nil, fmt.Errorf("invalid yamllint config: unknown option %q for rule %q", option, name)
+			}
+			rule.options[option] = optionValue
+		}
+		cfg.rules[name] = rule
+	}
+
+	return cfg, nil
+}
+
+func (r *ruleConfig) int(option string) int {
+	value, _ := r.options[option].(int)
+	return value
+}
+
+func (r *ruleConfig) bool(option string) bool {
+	value, _ := r.options[option].(bool)
+	return value
+}
+
+func (r *ruleConfig) strings(option string) []string {
+	values, _ := r.options[option].([]any)
+	var result []string
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			result = append(result, s)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
@@ -0,0 +1,167 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yamllint implements a subset of the rules of yamllint (https://github.com/adrienverge/yamllint), so YAML
+// files can be linted without a Python installation.
+package yamllint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a single finding of a rule.
+type Problem struct {
+	Line    int
+	Column  int
+	Level   string
+	Message string
+	Rule    string
+}
+
+func (p Problem) String() string {
+	// Mimics the 'standard' format of yamllint
+	line := fmt.Sprintf("  %d:%d", p.Line, p.Column)
+	line += strings.Repeat(" ", max(12-len(line), 0))
+	line += p.Level
+	line += strings.Repeat(" ", max(21-len(line), 0))
+	line += p.Message
+	if p.Rule != "" {
+		line += fmt.Sprintf("  (%s)", p.Rule)
+	}
+	return line
+}
+
+// LintFile lints the given file and prints the problems found to w. An error is returned if there is at least one
+// problem with level 'error'.
+func LintFile(w io.Writer, yamlFile string, cfg *Config) error {
+	content, err := os.ReadFile(yamlFile)
+	if err != nil {
+		return fmt.Errorf("failed reading %s: %w", yamlFile, err)
+	}
+
+	problems := Lint(content, cfg)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, yamlFile)
+	errorCount := 0
+	for _, problem := range problems {
+		fmt.Fprintln(w, problem)
+		if problem.Level == LevelError {
+			errorCount++
+		}
+	}
+	fmt.Fprintln(w)
+
+	if errorCount > 0 {
+		return fmt.Errorf("found %d yamllint error(s) in %s", errorCount, yamlFile)
+	}
+	return nil
+}
+
+var syntaxErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// Lint returns the problems found in the given YAML content ordered by position.
+func Lint(content []byte, cfg *Config) []Problem {
+	text := string(content)
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	if text == "" {
+		lines = nil
+	}
+
+	l := &linter{cfg: cfg, text: text, lines: lines}
+	l.checkLines()
+	l.checkTokens(scan(lines))
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			l.syntaxError(err)
+			break
+		}
+		l.checkDocument(&document)
+	}
+
+	slices.SortStableFunc(l.problems, func(a, b Problem) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return l.problems
+}
+
+type linter struct {
+	cfg      *Config
+	text     string
+	lines    []string
+	problems []Problem
+
+	// indentSpaces and indentSequences hold the indentation style detected for the rule 'indentation'
+	indentSpaces    int
+	indentSequences *bool
+}
+
+// report adds a problem for the given rule if the rule is enabled.
+func (l *linter) report(rule string, line, column int, format string, args ...any) {
+	r, ok := l.cfg.rules[rule]
+	if !ok {
+		return
+	}
+	l.problems = append(l.problems, Problem{
+		Line:    line,
+		Column:  column,
+		Level:   r.level,
+		Message: fmt.Sprintf(format, args...),
+		Rule:    rule,
+	})
+}
+
+func (l *linter) rule(name string) *ruleConfig {
+	return l.cfg.rules[name]
+}
+
+func (l *linter) syntaxError(err error) {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	line := 1
+	if match := syntaxErrorLine.FindStringSubmatch(err.Error()); match != nil {
+		line, _ = strconv.Atoi(match[1])
+		message = strings.TrimPrefix(err.Error(), match[0])
+	}
+	l.problems = append(l.problems, Problem{
+		Line:    line,
+		Column:  1,
+		Level:   LevelError,
+		Message: "syntax error: " + message + " (syntax)",
+	})
+}
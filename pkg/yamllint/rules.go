@@ -0,0 +1,492 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamllint
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// truthyValues are the values YAML 1.1 interprets as booleans.
+var truthyValues = []string{
+	"YES", "Yes", "yes", "NO", "No", "no",
+	"TRUE", "True", "true", "FALSE", "False", "false",
+	"ON", "On", "on", "OFF", "Off", "off",
+}
+
+// checkLines runs the rules which operate on the raw lines of the file.
+func (l *linter) checkLines() {
+	if r := l.rule("new-lines"); r != nil {
+		if i := strings.Index(l.text, "\n"); i >= 0 {
+			first := l.text[:i]
+			isDos := strings.HasSuffix(first, "\r")
+			if r.options["type"] == "dos" && !isDos {
+				l.report("new-lines", 1, len(first)+1, `wrong new line character: expected \r\n`)
+			} else if r.options["type"] != "dos" && isDos {
+				l.report("new-lines", 1, len(first), `wrong new line character: expected \n`)
+			}
+		}
+	}
+
+	for i, line := range l.lines {
+		lineNo := i + 1
+
+		if trimmed := strings.TrimRight(line, " \t"); trimmed != line {
+			l.report("trailing-spaces", lineNo, len(trimmed)+1, "trailing spaces")
+		}
+
+		if r := l.rule("line-length"); r != nil {
+			maxLength := r.int("max")
+			if length := utf8.RuneCountInString(line); length > maxLength && !isNonBreakable(r, line) {
+				l.report("line-length", lineNo, maxLength+1, "line too long (%d > %d characters)", length, maxLength)
+			}
+		}
+	}
+
+	if r := l.rule("empty-lines"); r != nil {
+		blankLines := 0
+		for i, line := range l.lines {
+			if line != "" {
+				blankLines = 0
+				continue
+			}
+			blankLines++
+			if i+1 < len(l.lines) && l.lines[i+1] == "" {
+				// Only report the last blank line of a series
+				continue
+			}
+
+			maxBlankLines := r.int("max")
+			if blankLines == i+1 {
+				maxBlankLines = r.int("max-start")
+			}
+			if i == len(l.lines)-1 {
+				maxBlankLines = r.int("max-end")
+			}
+			if blankLines > maxBlankLines {
+				l.report("empty-lines", i+1, 1, "too many blank lines (%d > %d)", blankLines, maxBlankLines)
+			}
+		}
+	}
+
+	if l.text != "" && !strings.HasSuffix(l.text, "\n") {
+		last := l.lines[len(l.lines)-1]
+		l.report("new-line-at-end-of-file", len(l.lines), len(last)+1, "no new line character at the end of file")
+	}
+}
+
+// isNonBreakable returns whether a long line is allowed because it cannot be broken.
+func isNonBreakable(r *ruleConfig, line string) bool {
+	if !r.bool("allow-non-breakable-words") {
+		return false
+	}
+
+	content := strings.TrimLeft(line, " ")
+	if content == "" {
+		return false
+	}
+	if strings.HasPrefix(content, "#") {
+		content = strings.TrimLeft(content, "#")
+		if content != "" {
+			content = content[1:]
+		}
+	} else if strings.HasPrefix(content, "-") {
+		content = content[min(2, len(content)):]
+	}
+	if !strings.Contains(content, " ") {
+		return true
+	}
+
+	if r.bool("allow-non-breakable-inline-mappings") {
+		// Inline mappings are allowed if their value cannot be broken
+		if _, value, found := strings.Cut(content, ": "); found && !strings.Contains(strings.TrimSpace(value), " ") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTokens runs the rules which operate on the tokens of the file.
+func (l *linter) checkTokens(tokens []token) {
+	var content []token
+	for _, t := range tokens {
+		if t.kind != tokenComment {
+			content = append(content, t)
+		}
+	}
+
+	for i, t := range content {
+		var prev, next *token
+		if i > 0 {
+			prev = &content[i-1]
+		}
+		if i+1 < len(content) {
+			next = &content[i+1]
+		}
+
+		switch t.kind {
+		case tokenFlowMappingStart:
+			l.checkFlowStart("braces", "flow mapping", t, next, tokenFlowMappingEnd)
+		case tokenFlowMappingEnd:
+			l.checkFlowEnd("braces", t, prev, tokenFlowMappingStart)
+		case tokenFlowSequenceStart:
+			l.checkFlowStart("brackets", "flow sequence", t, next, tokenFlowSequenceEnd)
+		case tokenFlowSequenceEnd:
+			l.checkFlowEnd("brackets", t, prev, tokenFlowSequenceStart)
+		case tokenColon:
+			if r := l.rule("colons"); r != nil && !(prev != nil && prev.kind == tokenAlias && t.col-prev.end == 1) {
+				l.spacesBefore("colons", t, prev, -1, r.int("max-spaces-before"), "", "too many spaces before colon")
+				l.spacesAfter("colons", t, next, -1, r.int("max-spaces-after"), "", "too many spaces after colon")
+			}
+		case tokenKey:
+			if r := l.rule("colons"); r != nil {
+				l.spacesAfter("colons", t, next, -1, r.int("max-spaces-after"), "", "too many spaces after question mark")
+			}
+		case tokenComma:
+			if r := l.rule("commas"); r != nil {
+				maxBefore := r.int("max-spaces-before")
+				if prev != nil && maxBefore != -1 && prev.endLine < t.line {
+					l.report("commas", t.line, max(1, t.col-1), "too many spaces before comma")
+				} else {
+					l.spacesBefore("commas", t, prev, -1, maxBefore, "", "too many spaces before comma")
+				}
+				l.spacesAfter("commas", t, next, r.int("min-spaces-after"), r.int("max-spaces-after"),
+					"too few spaces after comma", "too many spaces after comma")
+			}
+		case tokenHyphen:
+			if r := l.rule("hyphens"); r != nil {
+				l.spacesAfter("hyphens", t, next, -1, r.int("max-spaces-after"), "", "too many spaces after hyphen")
+			}
+		}
+	}
+
+	if r := l.rule("comments"); r != nil {
+		for i, t := range tokens {
+			if t.kind == tokenComment {
+				l.checkComment(r, t, tokens[:i])
+			}
+		}
+	}
+
+	if r := l.rule("document-start"); r != nil {
+		l.checkDocumentStart(r, content)
+	}
+}
+
+func (l *linter) checkFlowStart(rule, description string, t token, next *token, endKind tokenKind) {
+	r := l.rule(rule)
+	if r == nil {
+		return
+	}
+
+	isEmpty := next != nil && next.kind == endKind
+	forbid := r.options["forbid"]
+	if forbid == true || forbid == "non-empty" && !isEmpty {
+		l.report(rule, t.line, t.end, "forbidden %s", description)
+		return
+	}
+
+	if isEmpty {
+		minSpaces, maxSpaces := r.int("min-spaces-inside-empty"), r.int("max-spaces-inside-empty")
+		if minSpaces == -1 {
+			minSpaces = r.int("min-spaces-inside")
+		}
+		if maxSpaces == -1 {
+			maxSpaces = r.int("max-spaces-inside")
+		}
+		l.spacesAfter(rule, t, next, minSpaces, maxSpaces,
+			"too few spaces inside empty "+rule, "too many spaces inside empty "+rule)
+		return
+	}
+	l.spacesAfter(rule, t, next, r.int("min-spaces-inside"), r.int("max-spaces-inside"),
+		"too few spaces inside "+rule, "too many spaces inside "+rule)
+}
+
+func (l *linter) checkFlowEnd(rule string, t token, prev *token, startKind tokenKind) {
+	r := l.rule(rule)
+	if r == nil || prev == nil || prev.kind == startKind {
+		return
+	}
+	forbid := r.options["forbid"]
+	if forbid == true || forbid == "non-empty" {
+		// Already reported for the start of the collection
+		return
+	}
+	l.spacesBefore(rule, t, prev, r.int("min-spaces-inside"), r.int("max-spaces-inside"),
+		"too few spaces inside "+rule, "too many spaces inside "+rule)
+}
+
+// spacesAfter checks the number of spaces between a token and the next token on the same line. A limit of -1
+// disables the respective check.
+func (l *linter) spacesAfter(rule string, t token, next *token, minSpaces, maxSpaces int, minMessage, maxMessage string) {
+	if next == nil || next.line != t.endLine {
+		return
+	}
+	spaces := next.col - t.end
+	if maxSpaces != -1 && spaces > maxSpaces {
+		l.report(rule, t.endLine, next.col-1, "%s", maxMessage)
+	} else if minSpaces != -1 && spaces < minSpaces {
+		l.report(rule, t.endLine, next.col, "%s", minMessage)
+	}
+}
+
+// spacesBefore checks the number of spaces between a token and the previous token on the same line. A limit of -1
+// disables the respective check.
+func (l *linter) spacesBefore(rule string, t token, prev *token, minSpaces, maxSpaces int, minMessage, maxMessage string) {
+	if prev == nil || prev.endLine != t.line {
+		return
+	}
+	spaces := t.col - prev.end
+	if maxSpaces != -1 && spaces > maxSpaces {
+		l.report(rule, t.line, t.col-1, "%s", maxMessage)
+	} else if minSpaces != -1 && spaces < minSpaces {
+		l.report(rule, t.line, t.col, "%s", minMessage)
+	}
+}
+
+func (l *linter) checkComment(r *ruleConfig, comment token, before []token) {
+	if r.bool("require-starting-space") {
+		text := strings.TrimLeft(comment.text, "#")
+		isShebang := comment.line == 1 && comment.col == 1 && strings.HasPrefix(text, "!") && len(text) > 1 &&
+			!isBlank(text[1])
+		if text != "" && !isBlank(text[0]) && !(r.bool("ignore-shebangs") && isShebang) {
+			l.report("comments", comment.line, comment.col+len(comment.text)-len(text),
+				"missing starting space in comment")
+		}
+	}
+
+	minSpaces := r.int("min-spaces-from-content")
+	if minSpaces != -1 && len(before) > 0 {
+		prev := before[len(before)-1]
+		if prev.endLine == comment.line && comment.col-prev.end < minSpaces {
+			l.report("comments", comment.line, comment.col, "too few spaces before comment")
+		}
+	}
+}
+
+func (l *linter) checkDocumentStart(r *ruleConfig, tokens []token) {
+	if !r.bool("present") {
+		for _, t := range tokens {
+			if t.kind == tokenDocumentStart {
+				l.report("document-start", t.line, t.col, `found forbidden document start "---"`)
+			}
+		}
+		return
+	}
+
+	// Every document which is not explicitly started must be the first one or follow an explicit document end
+	atDocumentStart := true
+	for _, t := range tokens {
+		if atDocumentStart && t.kind != tokenDocumentStart && t.kind != tokenDocumentEnd {
+			l.report("document-start", t.line, 1, `missing document start "---"`)
+		}
+		atDocumentStart = t.kind == tokenDocumentEnd
+	}
+}
+
+// checkDocument runs the rules which operate on the parsed document.
+func (l *linter) checkDocument(document *yaml.Node) {
+	if len(document.Content) == 0 {
+		return
+	}
+	root := document.Content[0]
+
+	if l.rule("key-duplicates") != nil {
+		walk(root, l.checkKeyDuplicates)
+	}
+	if r := l.rule("truthy"); r != nil {
+		l.checkTruthy(r, root, false)
+	}
+	if r := l.rule("indentation"); r != nil {
+		if isBlockCollection(root) && root.Column != 1 {
+			l.reportIndentation(root.Line, 0, root.Column-1)
+		}
+		l.checkIndentation(r, root)
+	}
+}
+
+func (l *linter) checkKeyDuplicates(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if key.Kind != yaml.ScalarNode || key.Value == "<<" && key.Style == 0 {
+			continue
+		}
+		if seen[key.Value] {
+			l.report("key-duplicates", key.Line, key.Column, "duplication of key %q in mapping", key.Value)
+		}
+		seen[key.Value] = true
+	}
+}
+
+func (l *linter) checkTruthy(r *ruleConfig, node *yaml.Node, isKey bool) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if isKey && !r.bool("check-keys") || node.Style != 0 {
+			// Quoted, block and explicitly tagged scalars are not interpreted as booleans
+			return
+		}
+		allowed := r.strings("allowed-values")
+		if slices.Contains(truthyValues, node.Value) && !slices.Contains(allowed, node.Value) {
+			l.report("truthy", node.Line, node.Column, "truthy value should be one of [%s]", strings.Join(allowed, ", "))
+		}
+	case yaml.MappingNode:
+		for i, child := range node.Content {
+			l.checkTruthy(r, child, i%2 == 0)
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			l.checkTruthy(r, child, false)
+		}
+	}
+}
+
+// checkIndentation checks the indentation of the block collections nested in node.
+func (l *linter) checkIndentation(r *ruleConfig, node *yaml.Node) {
+	if !isBlockCollection(node) {
+		return
+	}
+
+	_, column := l.position(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Column != column {
+				l.reportIndentation(key.Line, column-1, key.Column-1)
+			}
+			valueLine, _ := l.position(value)
+			if valueLine > key.Line && value.Kind == yaml.MappingNode && isBlockCollection(value) {
+				l.checkNestedIndentation(r, value, column-1)
+			} else if valueLine > key.Line && value.Kind == yaml.SequenceNode && isBlockCollection(value) {
+				l.checkSequenceIndentation(r, value, column-1)
+			}
+			l.checkIndentation(r, value)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if dash := l.dashColumn(item); dash == 0 {
+				// The item starts on the line following its sequence entry indicator
+				if isBlockCollection(item) {
+					l.checkNestedIndentation(r, item, column-1)
+				}
+			} else if dash != column {
+				l.reportIndentation(item.Line, column-1, dash-1)
+			}
+			l.checkIndentation(r, item)
+		}
+	}
+}
+
+// checkNestedIndentation checks that node is indented by the configured number of spaces relative to parentIndent.
+func (l *linter) checkNestedIndentation(r *ruleConfig, node *yaml.Node, parentIndent int) {
+	line, column := l.position(node)
+	found := column - 1
+	if spaces, ok := r.options["spaces"].(int); ok {
+		l.indentSpaces = spaces
+	} else if l.indentSpaces == 0 && found > parentIndent {
+		l.indentSpaces = found - parentIndent
+	}
+	if expected := parentIndent + l.indentSpaces; found != expected {
+		l.reportIndentation(line, expected, found)
+	}
+}
+
+// checkSequenceIndentation checks the indentation of a sequence which is the value of a mapping key.
+func (l *linter) checkSequenceIndentation(r *ruleConfig, node *yaml.Node, keyIndent int) {
+	line, column := l.position(node)
+	found := column - 1
+	isIndented := found > keyIndent
+
+	switch r.options["indent-sequences"] {
+	case false:
+		if isIndented {
+			l.reportIndentation(line, keyIndent, found)
+		}
+		return
+	case "whatever":
+		if !isIndented {
+			return
+		}
+	case "consistent":
+		if l.indentSequences == nil {
+			l.indentSequences = &isIndented
+		}
+		if !*l.indentSequences {
+			if isIndented {
+				l.reportIndentation(line, keyIndent, found)
+			}
+			return
+		}
+	}
+	l.checkNestedIndentation(r, node, keyIndent)
+}
+
+func (l *linter) reportIndentation(line, expected, found int) {
+	l.report("indentation", line, found+1, "wrong indentation: expected %d but found %d", expected, found)
+}
+
+// position returns the line and column at which the entries of the block collection node start. yaml.v3 positions a
+// collection with an anchor or a tag, e.g. 'key: &anchor' followed by indented entries, at the anchor or tag instead.
+func (l *linter) position(node *yaml.Node) (line, column int) {
+	if node.Anchor == "" && node.Style&yaml.TaggedStyle == 0 || !isBlockCollection(node) || len(node.Content) == 0 {
+		return node.Line, node.Column
+	}
+	if node.Kind == yaml.MappingNode {
+		return node.Content[0].Line, node.Content[0].Column
+	}
+	// The first entry indicator of the sequence begins the first line after the properties which isn't blank or a comment
+	for i := node.Line; i < len(l.lines); i++ {
+		if text := strings.TrimLeft(l.lines[i], " \t"); text != "" && !strings.HasPrefix(text, "#") {
+			return i + 1, len(l.lines[i]) - len(text) + 1
+		}
+	}
+	return node.Line, node.Column
+}
+
+// dashColumn returns the column of the sequence entry indicator preceding item on the same line, or 0 if the item
+// starts on a later line than its indicator.
+func (l *linter) dashColumn(item *yaml.Node) int {
+	if item.Line < 1 || item.Line > len(l.lines) {
+		return 0
+	}
+	line := l.lines[item.Line-1]
+	for i := min(item.Column-2, len(line)-1); i >= 0; i-- {
+		if line[i] == '-' {
+			return i + 1
+		}
+		if !isBlank(line[i]) {
+			return 0
+		}
+	}
+	return 0
+}
+
+func isBlockCollection(node *yaml.Node) bool {
+	return (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && node.Style&yaml.FlowStyle == 0
+}
+
+func walk(node *yaml.Node, fn func(*yaml.Node)) {
+	fn(node)
+	for _, child := range node.Content {
+		walk(child, fn)
+	}
+}
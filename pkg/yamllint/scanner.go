@@ -0,0 +1,300 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamllint
+
+import (
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenScalar tokenKind = iota
+	tokenBlockScalar
+	tokenFlowMappingStart
+	tokenFlowMappingEnd
+	tokenFlowSequenceStart
+	tokenFlowSequenceEnd
+	tokenComma
+	tokenColon
+	tokenHyphen
+	tokenKey
+	tokenAlias
+	tokenProperty
+	tokenComment
+	tokenDocumentStart
+	tokenDocumentEnd
+)
+
+// token is a lexical element of a YAML document. Lines and columns are 1-based, end is the column after the last
+// character on endLine.
+type token struct {
+	kind    tokenKind
+	line    int
+	col     int
+	endLine int
+	end     int
+	text    string
+}
+
+// scanner splits YAML documents into the tokens the rules operate on. It does not validate the document, syntax errors
+// are reported by the parser.
+type scanner struct {
+	lines  []string
+	tokens []token
+
+	line      int
+	col       int
+	flowDepth int
+
+	// blockScalarIndent is the indentation content lines of the current block scalar must exceed, or -2 if the
+	// scanner is not inside a block scalar
+	blockScalarIndent int
+}
+
+func scan(lines []string) []token {
+	s := &scanner{lines: lines, blockScalarIndent: -2}
+	for s.line < len(s.lines) {
+		s.scanLine()
+	}
+	return s.tokens
+}
+
+func (s *scanner) scanLine() {
+	line := s.lines[s.line]
+
+	if s.blockScalarIndent > -2 {
+		if isDocumentMarker(line, "---") || isDocumentMarker(line, "...") ||
+			strings.TrimSpace(line) != "" && indentation(line) <= s.blockScalarIndent {
+			s.blockScalarIndent = -2
+		} else {
+			s.line++
+			return
+		}
+	}
+
+	s.col = 0
+	if s.flowDepth == 0 {
+		switch {
+		case strings.HasPrefix(line, "%"):
+			s.line++
+			return
+		case isDocumentMarker(line, "---"):
+			s.emit(tokenDocumentStart, s.line, 0, s.line, 3)
+			s.col = 3
+		case isDocumentMarker(line, "..."):
+			s.emit(tokenDocumentEnd, s.line, 0, s.line, 3)
+			s.col = 3
+		}
+	}
+
+	for {
+		line := s.lines[s.line]
+		s.skipBlanks(line)
+		if s.col >= len(line) {
+			break
+		}
+		// Quoted scalars may move the scanner to a later line
+		s.scanToken(line)
+	}
+	s.line++
+}
+
+func (s *scanner) scanToken(line string) {
+	c := line[s.col]
+	next := byte(0)
+	if s.col+1 < len(line) {
+		next = line[s.col+1]
+	}
+	start := s.col
+
+	switch {
+	case c == '#':
+		s.emit(tokenComment, s.line, start, s.line, len(line))
+		s.col = len(line)
+	case c == '{' || c == '[':
+		kind := tokenFlowMappingStart
+		if c == '[' {
+			kind = tokenFlowSequenceStart
+		}
+		s.flowDepth++
+		s.emitSingle(kind)
+	case c == '}' || c == ']':
+		kind := tokenFlowMappingEnd
+		if c == ']' {
+			kind = tokenFlowSequenceEnd
+		}
+		if s.flowDepth > 0 {
+			s.flowDepth--
+		}
+		s.emitSingle(kind)
+	case c == ',' && s.flowDepth > 0:
+		s.emitSingle(tokenComma)
+	case c == '-' && s.flowDepth == 0 && isBlankOrEnd(next):
+		s.emitSingle(tokenHyphen)
+	case c == '?' && isBlankOrEnd(next):
+		s.emitSingle(tokenKey)
+	case c == ':' && (isBlankOrEnd(next) || s.flowDepth > 0):
+		s.emitSingle(tokenColon)
+	case c == '&' || c == '*' || c == '!':
+		for s.col < len(line) && !isBlank(line[s.col]) && !(s.flowDepth > 0 && isFlowIndicator(line[s.col])) {
+			s.col++
+		}
+		kind := tokenProperty
+		if c == '*' {
+			kind = tokenAlias
+		}
+		s.emit(kind, s.line, start, s.line, s.col)
+	case c == '\'' || c == '"':
+		s.scanQuoted(c)
+	case (c == '|' || c == '>') && s.flowDepth == 0:
+		for s.col < len(line) && !isBlank(line[s.col]) {
+			s.col++
+		}
+		s.emit(tokenBlockScalar, s.line, start, s.line, s.col)
+		s.blockScalarIndent = s.blockScalarParentIndent()
+	default:
+		s.scanPlain(line)
+	}
+}
+
+// scanQuoted scans a single or double-quoted scalar, which may span multiple lines.
+func (s *scanner) scanQuoted(quote byte) {
+	startLine, startCol := s.line, s.col
+	s.col++
+	for s.line < len(s.lines) {
+		line := s.lines[s.line]
+		for s.col < len(line) {
+			c := line[s.col]
+			switch {
+			case quote == '"' && c == '\\':
+				s.col += 2
+			case c == quote && quote == '\'' && s.col+1 < len(line) && line[s.col+1] == '\'':
+				s.col += 2
+			case c == quote:
+				s.col++
+				s.emit(tokenScalar, startLine, startCol, s.line, s.col)
+				return
+			default:
+				s.col++
+			}
+		}
+		s.line++
+		s.col = 0
+	}
+	// Unterminated quoted scalar
+	s.line = len(s.lines) - 1
+	s.col = len(s.lines[s.line])
+	s.emit(tokenScalar, startLine, startCol, s.line, s.col)
+}
+
+// scanPlain scans a plain scalar up to the end of the line, a comment, or a value indicator.
+func (s *scanner) scanPlain(line string) {
+	start := s.col
+	end := s.col
+	for s.col < len(line) {
+		c := line[s.col]
+		next := byte(0)
+		if s.col+1 < len(line) {
+			next = line[s.col+1]
+		}
+		if c == ':' && (isBlankOrEnd(next) || s.flowDepth > 0 && isFlowIndicator(next)) {
+			break
+		}
+		if c == '#' && s.col > start && isBlank(line[s.col-1]) {
+			break
+		}
+		if s.flowDepth > 0 && isFlowIndicator(c) {
+			break
+		}
+		s.col++
+		if !isBlank(c) {
+			end = s.col
+		}
+	}
+	s.col = end
+	s.emit(tokenScalar, s.line, start, s.line, end)
+}
+
+// blockScalarParentIndent returns the indentation of the node a block scalar indicator belongs to.
+func (s *scanner) blockScalarParentIndent() int {
+	if len(s.tokens) < 2 {
+		return -1
+	}
+	prev := s.tokens[len(s.tokens)-2]
+	switch prev.kind {
+	case tokenHyphen:
+		return prev.col - 1
+	case tokenColon:
+		// The indentation of a mapping is the column of its first key, which is the first token on the line that
+		// is neither a document start nor a sequence entry indicator
+		first := len(s.tokens) - 2
+		for first > 0 && s.tokens[first-1].endLine == prev.line {
+			first--
+		}
+		for first < len(s.tokens)-2 &&
+			(s.tokens[first].kind == tokenHyphen || s.tokens[first].kind == tokenDocumentStart) {
+			first++
+		}
+		return s.tokens[first].col - 1
+	}
+	return -1
+}
+
+func (s *scanner) skipBlanks(line string) {
+	for s.col < len(line) && isBlank(line[s.col]) {
+		s.col++
+	}
+}
+
+func (s *scanner) emitSingle(kind tokenKind) {
+	s.emit(kind, s.line, s.col, s.line, s.col+1)
+	s.col++
+}
+
+func (s *scanner) emit(kind tokenKind, line, col, endLine, end int) {
+	text := s.lines[line][col:]
+	if endLine == line {
+		text = s.lines[line][col:end]
+	}
+	s.tokens = append(s.tokens, token{
+		kind:    kind,
+		line:    line + 1,
+		col:     col + 1,
+		endLine: endLine + 1,
+		end:     end + 1,
+		text:    text,
+	})
+}
+
+func isDocumentMarker(line string, marker string) bool {
+	return line == marker || strings.HasPrefix(line, marker+" ") || strings.HasPrefix(line, marker+"\t")
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isBlankOrEnd(c byte) bool {
+	return c == 0 || isBlank(c)
+}
+
+func isFlowIndicator(c byte) bool {
+	return c == ',' || c == '[' || c == ']' || c == '{' || c == '}'
+}
@@ -0,0 +1,236 @@
+// Copyright The Helm Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamllint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	var testDataSet = []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{"repo lintconf", "", ""},
+		{"disabled unsupported rule", "rules:\n  float-values: disable\n", ""},
+		{"enabled unsupported rule", "rules:\n  float-values: enable\n", `rule "float-values" is not supported`},
+		{"extends", "extends: default\n", "'extends' is not supported"},
+		{"unknown option", "rules:\n  colons:\n    max-spaces: 1\n", `unknown option "max-spaces" for rule "colons"`},
+		{"invalid level", "rules:\n  colons:\n    level: info\n", `level of rule "colons" must be 'error' or 'warning'`},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			config := []byte(testData.config)
+			if testData.config == "" {
+				var err error
+				config, err = os.ReadFile("../../etc/lintconf.yaml")
+				require.NoError(t, err)
+			}
+
+			_, err := ParseConfig(config)
+			if testData.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, testData.expectedError)
+			}
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	var testDataSet = []struct {
+		name     string
+		rules    string
+		yaml     string
+		expected []string
+	}{
+		{"valid", "trailing-spaces: enable", "a: 1\n", nil},
+		{"trailing spaces", "trailing-spaces: enable", "a: 1 \nb: 2\t\n", []string{"1:5 trailing spaces", "2:5 trailing spaces"}},
+		{"missing new line", "new-line-at-end-of-file: enable", "a: 1\nb: 2", []string{"2:5 no new line character at the end of file"}},
+		{"dos new lines", "new-lines: {type: unix}", "a: 1\r\nb: 2\r\n", []string{`1:5 wrong new line character: expected \n`}},
+		{"unix new lines", "new-lines: {type: dos}", "a: 1\nb: 2\n", []string{`1:5 wrong new line character: expected \r\n`}},
+		{
+			"empty lines",
+			"empty-lines: {max: 1, max-start: 0, max-end: 0}",
+			"\na: 1\n\n\nb: 2\n\n",
+			[]string{"1:1 too many blank lines (1 > 0)", "4:1 too many blank lines (2 > 1)", "6:1 too many blank lines (1 > 0)"},
+		},
+		{
+			"line length",
+			"line-length: {max: 10}",
+			"a: 1\nkey: a b c d e\nurl: http://example.com/very/long\n# https://example.com/very/long\n",
+			[]string{"2:11 line too long (14 > 10 characters)", "3:11 line too long (33 > 10 characters)"},
+		},
+		{
+			"document start",
+			"document-start: {present: true}",
+			"# comment\na: 1\n---\nb: 2\n",
+			[]string{`2:1 missing document start "---"`},
+		},
+		{"forbidden document start", "document-start: {present: false}", "---\na: 1\n", []string{`1:1 found forbidden document start "---"`}},
+		{
+			"braces",
+			"braces: {max-spaces-inside: 0}",
+			"a: {b: 1}\nc: { d: 1 }\ne: {}\nf: { }\n",
+			[]string{"2:5 too many spaces inside braces", "2:10 too many spaces inside braces", "4:5 too many spaces inside empty braces"},
+		},
+		{
+			"braces min spaces",
+			"braces: {min-spaces-inside: 1, max-spaces-inside: 1, min-spaces-inside-empty: 0, max-spaces-inside-empty: 0}",
+			"a: { b: 1 }\nc: {d: 1}\ne: {}\n",
+			[]string{"2:5 too few spaces inside braces", "2:9 too few spaces inside braces"},
+		},
+		{"forbidden braces", "braces: {forbid: non-empty}", "a: {}\nb: {c: 1}\n", []string{"2:5 forbidden flow mapping"}},
+		{
+			"brackets",
+			"brackets: enable",
+			"a: [1, 2]\nb: [ 1, 2 ]\n",
+			[]string{"2:5 too many spaces inside brackets", "2:10 too many spaces inside brackets"},
+		},
+		{
+			"colons",
+			"colons: enable",
+			"a: 1\nb : 2\nc:  3\nurl: http://example.com\n? d\n: 4\n",
+			[]string{"2:2 too many spaces before colon", "3:4 too many spaces after colon"},
+		},
+		{
+			"commas",
+			"commas: enable",
+			"a: [1, 2]\nb: [1 , 2]\nc: [1,2]\nd: [1,  2]\n",
+			[]string{"2:6 too many spaces before comma", "3:7 too few spaces after comma", "4:8 too many spaces after comma"},
+		},
+		{
+			"hyphens",
+			"hyphens: enable",
+			"a:\n  - 1\n  -  2\nb: -1\n",
+			[]string{"3:5 too many spaces after hyphen"},
+		},
+		{
+			"comments",
+			"comments: enable",
+			"#!/usr/bin/env ct\n# valid\n#invalid\n##invalid\na: 1 # too close\nb: 2  # valid\nc: '#' # too close\n#\n",
+			[]string{"3:2 missing starting space in comment", "4:3 missing starting space in comment", "5:6 too few spaces before comment", "7:8 too few spaces before comment"},
+		},
+		{
+			"comments in block scalars",
+			"comments: enable",
+			"a: |\n  #not a comment\n  b: 1 # still text\nc: >-\n  #text\n# comment\n",
+			nil,
+		},
+		{
+			"key duplicates",
+			"key-duplicates: enable",
+			"a: 1\nb:\n  c: 1\n  c: 2\na: 3\nbase: &base\n  d: 1\nx:\n  <<: *base\n  <<: *base\n",
+			[]string{`4:3 duplication of key "c" in mapping`, `5:1 duplication of key "a" in mapping`},
+		},
+		{
+			"truthy",
+			"truthy: enable",
+			"a: yes\nb: true\nc: 'on'\nd: !!str off\ne: True\nno: 1\n",
+			[]string{"1:4 truthy value should be one of [false, true]", "5:4 truthy value should be one of [false, true]", "6:1 truthy value should be one of [false, true]"},
+		},
+		{
+			"truthy allowed values",
+			"truthy: {allowed-values: ['yes', 'no'], check-keys: false}",
+			"a: yes\nb: true\non: 1\n",
+			[]string{"2:4 truthy value should be one of [no, yes]"},
+		},
+		{
+			"indentation",
+			"indentation: {spaces: consistent, indent-sequences: true}",
+			"a:\n  b:\n    c: 1\n  d:\n   e: 1\nf:\n  - g: 1\n    h: 2\n  - i\ng:\n- j\n",
+			[]string{"5:4 wrong indentation: expected 4 but found 3", "11:1 wrong indentation: expected 2 but found 0"},
+		},
+		{
+			"indentation fixed spaces",
+			"indentation: {spaces: 4, indent-sequences: false}",
+			"a:\n  b: 1\nc:\n    d: 1\ne:\n  - 1\nf:\n- 2\n",
+			[]string{"2:3 wrong indentation: expected 4 but found 2", "6:3 wrong indentation: expected 0 but found 2"},
+		},
+		{
+			"indentation whatever",
+			"indentation: {spaces: consistent, indent-sequences: whatever}",
+			"a:\n  - 1\nb:\n- 2\nc:\n   - 3\n",
+			[]string{"6:4 wrong indentation: expected 2 but found 3"},
+		},
+		{
+			"indentation consistent sequences",
+			"indentation: {spaces: consistent, indent-sequences: consistent}",
+			"a:\n- 1\nb:\n  - 2\n",
+			[]string{"4:3 wrong indentation: expected 0 but found 2"},
+		},
+		{
+			"indentation ignores flow collections and block scalars",
+			"indentation: {spaces: 2}",
+			"a: {b: 1,\n      c: 2}\nd: |\n     text\n      more\n",
+			nil,
+		},
+		{
+			"indentation of anchored, tagged and merged collections",
+			"indentation: {spaces: consistent, indent-sequences: true}",
+			"base: &a\n  k: v\nlist: &l # comment\n  - 1\ntagged: !!map\n  k: v\nseq: !!seq\n\n  -\n    k: v\nmerged:\n  <<: *a\n  j: w\n",
+			nil,
+		},
+		{
+			"wrong indentation of anchored and tagged collections",
+			"indentation: {spaces: 2, indent-sequences: true}",
+			"a: &a\n   k: v\nb: !!seq\n- 1\n",
+			[]string{"2:4 wrong indentation: expected 2 but found 3", "4:1 wrong indentation: expected 2 but found 0"},
+		},
+		{"syntax error", "trailing-spaces: enable", "a: 1\n b: 2\n", []string{"2:1 syntax error: mapping values are not allowed in this context (syntax)"}},
+	}
+
+	for _, testData := range testDataSet {
+		t.Run(testData.name, func(t *testing.T) {
+			cfg, err := ParseConfig([]byte("rules:\n  " + testData.rules + "\n"))
+			require.NoError(t, err)
+
+			var actual []string
+			for _, problem := range Lint([]byte(testData.yaml), cfg) {
+				actual = append(actual, fmt.Sprintf("%d:%d %s", problem.Line, problem.Column, problem.Message))
+			}
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestLintFile(t *testing.T) {
+	cfg, err := ParseConfig([]byte("rules:\n  trailing-spaces: enable\n  truthy:\n    level: warning\n"))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	warningsOnly := filepath.Join(dir, "warnings.yaml")
+	require.NoError(t, os.WriteFile(warningsOnly, []byte("a: yes\n"), 0644))
+	withErrors := filepath.Join(dir, "errors.yaml")
+	require.NoError(t, os.WriteFile(withErrors, []byte("a: yes \n"), 0644))
+
+	var b strings.Builder
+	assert.NoError(t, LintFile(&b, warningsOnly, cfg))
+	assert.Equal(t, warningsOnly+"\n  1:4       warning  truthy value should be one of [false, true]  (truthy)\n\n", b.String())
+
+	b.Reset()
+	err = LintFile(&b, withErrors, cfg)
+	assert.EqualError(t, err, "found 1 yamllint error(s) in "+withErrors)
+	assert.Contains(t, b.String(), "  1:7       error    trailing spaces  (trailing-spaces)\n")
+}